@@ -1,20 +1,121 @@
 package aws
 
 import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
-func NewSession(region, profile string) *session.Session {
-	return session.Must(
-		session.NewSessionWithOptions(
-			session.Options{
-				SharedConfigState: session.SharedConfigEnable,
-				Config: aws.Config{
-					Region: aws.String(region),
-				},
-				Profile: profile,
-			},
-		),
-	)
+// Options configure how sessions reach AWS. The zero value uses the SDK defaults.
+type Options struct {
+	Endpoint    string // URL of the SSM API in every region, e.g. LocalStack or ssmsh-fake-server
+	KMSEndpoint string // URL of the KMS API
+	STSEndpoint string // URL of the STS API
+	FIPS        bool   // Use FIPS 140-2 validated endpoints
+	DualStack   bool   // Use endpoints that accept IPv4 and IPv6
+	CABundle    string // PEM file of certificate authorities to trust instead of the system's
+	MaxRetries  *int   // How many times to retry failed requests, or nil for the SDK default
+}
+
+// Validate returns an error if sessions cannot be created with the options
+func (o Options) Validate() error {
+	for _, endpoint := range []string{o.Endpoint, o.KMSEndpoint, o.STSEndpoint} {
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint %s: %s", endpoint, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid endpoint %s: expected a URL such as https://ssm.example.com", endpoint)
+		}
+	}
+	if o.CABundle != "" {
+		pem, err := os.ReadFile(o.CABundle)
+		if err != nil {
+			return err
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA bundle %s", o.CABundle)
+		}
+	}
+	if o.MaxRetries != nil && *o.MaxRetries < 0 {
+		return errors.New("max retries cannot be negative")
+	}
+	return nil
+}
+
+// NewSession creates a session for a region and profile. The endpoints are set by the clients
+// rather than the session.
+func NewSession(region, profile string, opts Options) (*session.Session, error) {
+	cfg := aws.Config{
+		Region:     aws.String(region),
+		MaxRetries: opts.MaxRetries,
+	}
+	if opts.FIPS {
+		cfg.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+	}
+	if opts.DualStack {
+		cfg.UseDualStackEndpoint = endpoints.DualStackEndpointStateEnabled
+	}
+	sessionOpts := session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Config:            cfg,
+		Profile:           profile,
+	}
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		sessionOpts.CustomCABundle = bytes.NewReader(pem)
+	}
+	return session.NewSessionWithOptions(sessionOpts)
+}
+
+// NewSSM creates an SSM client for a region and profile
+func NewSSM(region, profile string, opts Options) (*ssm.SSM, error) {
+	sess, err := NewSession(region, profile, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ssm.New(sess, endpoint(opts.Endpoint)), nil
+}
+
+// NewKMS creates a KMS client for a region and profile
+func NewKMS(region, profile string, opts Options) (*kms.KMS, error) {
+	sess, err := NewSession(region, profile, opts)
+	if err != nil {
+		return nil, err
+	}
+	return kms.New(sess, endpoint(opts.KMSEndpoint)), nil
+}
+
+// NewSTS creates an STS client for a region and profile
+func NewSTS(region, profile string, opts Options) (*sts.STS, error) {
+	sess, err := NewSession(region, profile, opts)
+	if err != nil {
+		return nil, err
+	}
+	return sts.New(sess, endpoint(opts.STSEndpoint)), nil
+}
+
+// endpoint returns the configuration of a client that uses an endpoint, if one is set
+func endpoint(endpoint string) *aws.Config {
+	cfg := aws.NewConfig()
+	if endpoint != "" {
+		cfg.Endpoint = aws.String(endpoint)
+	}
+	return cfg
 }
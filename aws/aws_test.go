@@ -0,0 +1,130 @@
+package aws_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	saws "github.com/bwhaley/ssmsh/aws"
+)
+
+const testCert = `-----BEGIN CERTIFICATE-----
+MIIBgDCCASWgAwIBAgIUDdLB8KKss87tz3yfNgK9aDpC6ngwCgYIKoZIzj0EAwIw
+FTETMBEGA1UEAwwKc3Ntc2gtdGVzdDAeFw0yNjEwMTYyMTAyMTBaFw0zNjEwMTMy
+MTAyMTBaMBUxEzARBgNVBAMMCnNzbXNoLXRlc3QwWTATBgcqhkjOPQIBBggqhkjO
+PQMBBwNCAAR68YhlVwmUTRffBGtCGGGS+OkmWHvosf6MhmuZEZVyE+o9N2E1hYLo
+OMTVS+4xg42x06ssG9QCk7VBYmktsJjho1MwUTAdBgNVHQ4EFgQUi5U6i0g9BYSO
+/J5uBy6AvIU2dGUwHwYDVR0jBBgwFoAUi5U6i0g9BYSO/J5uBy6AvIU2dGUwDwYD
+VR0TAQH/BAUwAwEB/zAKBggqhkjOPQQDAgNJADBGAiEA4G64/l2JWe7dM893sueM
+5zOH7ngNVN0hS6LvBrHB3T0CIQDhEkyzmZDTelegkEMPHeq9ItDQUbCteh1IbQPz
+WSGGHg==
+-----END CERTIFICATE-----
+`
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	caBundle := filepath.Join(dir, "ca.pem")
+	err := os.WriteFile(caBundle, []byte(testCert), 0600)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	notPEM := filepath.Join(dir, "not.pem")
+	err = os.WriteFile(notPEM, []byte("not a certificate"), 0600)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	retries := func(n int) *int { return &n }
+
+	cases := []struct {
+		Name  string
+		Opts  saws.Options
+		Valid bool
+	}{
+		{"defaults", saws.Options{}, true},
+		{"https endpoint", saws.Options{Endpoint: "https://ssm.example.com"}, true},
+		{"http endpoint", saws.Options{Endpoint: "http://localhost:4583"}, true},
+		{"endpoint without scheme", saws.Options{Endpoint: "ssm.example.com"}, false},
+		{"endpoint with another scheme", saws.Options{Endpoint: "ftp://ssm.example.com"}, false},
+		{"endpoint without host", saws.Options{Endpoint: "https://"}, false},
+		{"unparseable endpoint", saws.Options{Endpoint: "https://ssm example.com:port"}, false},
+		{"KMS endpoint", saws.Options{KMSEndpoint: "https://kms.example.com"}, true},
+		{"KMS endpoint without scheme", saws.Options{KMSEndpoint: "kms.example.com"}, false},
+		{"CA bundle", saws.Options{CABundle: caBundle}, true},
+		{"missing CA bundle", saws.Options{CABundle: filepath.Join(dir, "missing.pem")}, false},
+		{"CA bundle without certificates", saws.Options{CABundle: notPEM}, false},
+		{"retries", saws.Options{MaxRetries: retries(3)}, true},
+		{"no retries", saws.Options{MaxRetries: retries(0)}, true},
+		{"negative retries", saws.Options{MaxRetries: retries(-1)}, false},
+	}
+	for _, c := range cases {
+		err := c.Opts.Validate()
+		if c.Valid && err != nil {
+			t.Fatalf("%s: unexpected error %s", c.Name, err)
+		}
+		if !c.Valid && err == nil {
+			t.Fatalf("%s: expected an error", c.Name)
+		}
+	}
+}
+
+func TestClientEndpoints(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+	endpoints := saws.Options{
+		Endpoint:    "http://localhost:4583",
+		KMSEndpoint: "http://localhost:4599",
+		STSEndpoint: "http://localhost:4592",
+	}
+
+	cases := []struct {
+		Name   string
+		Opts   saws.Options
+		Region string
+		SSM    string
+		KMS    string
+		STS    string
+	}{
+		{"defaults", saws.Options{}, "us-east-1", "https://ssm.us-east-1.amazonaws.com", "https://kms.us-east-1.amazonaws.com", "https://sts.amazonaws.com"},
+		{"FIPS", saws.Options{FIPS: true}, "us-east-1", "https://ssm-fips.us-east-1.amazonaws.com", "https://kms-fips.us-east-1.amazonaws.com", "https://sts-fips.us-east-1.amazonaws.com"},
+		{"endpoints", endpoints, "us-east-1", "http://localhost:4583", "http://localhost:4599", "http://localhost:4592"},
+		{"endpoints in another region", endpoints, "us-west-2", "http://localhost:4583", "http://localhost:4599", "http://localhost:4592"},
+		{"SSM endpoint only", saws.Options{Endpoint: "http://localhost:4583"}, "us-west-2", "http://localhost:4583", "https://kms.us-west-2.amazonaws.com", "https://sts.amazonaws.com"},
+	}
+	for _, c := range cases {
+		ssm, err := saws.NewSSM(c.Region, "", c.Opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", c.Name, err)
+		}
+		if ssm.Endpoint != c.SSM {
+			t.Fatalf("%s: expected SSM endpoint %s, got %s", c.Name, c.SSM, ssm.Endpoint)
+		}
+		kms, err := saws.NewKMS(c.Region, "", c.Opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", c.Name, err)
+		}
+		if kms.Endpoint != c.KMS {
+			t.Fatalf("%s: expected KMS endpoint %s, got %s", c.Name, c.KMS, kms.Endpoint)
+		}
+		sts, err := saws.NewSTS(c.Region, "", c.Opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", c.Name, err)
+		}
+		if sts.Endpoint != c.STS {
+			t.Fatalf("%s: expected STS endpoint %s, got %s", c.Name, c.STS, sts.Endpoint)
+		}
+	}
+}
+
+func TestNewSessionErrors(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+	opts := saws.Options{CABundle: filepath.Join(t.TempDir(), "missing.pem")}
+	_, err := saws.NewSession("us-east-1", "", opts)
+	if err == nil {
+		t.Fatal("expected an error for a missing CA bundle")
+	}
+	_, err = saws.NewSSM("us-east-1", "", opts)
+	if err == nil {
+		t.Fatal("expected an error creating a client with a missing CA bundle")
+	}
+}
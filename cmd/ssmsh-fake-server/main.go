@@ -0,0 +1,77 @@
+// ssmsh-fake-server serves the SSM parameter store API from memory, for testing ssmsh and other
+// tools offline. Parameters are loaded from a state file at startup and saved to it after every change.
+//
+//	$ ssmsh-fake-server -state params.json
+//	$ ssmsh -endpoint http://localhost:4583
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bwhaley/ssmsh/parameterstore/fake"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:4583", "Address to listen on")
+	stateFile := flag.String("state", "", "Load parameters from and save them to the specified file")
+	region := flag.String("region", fake.DefaultRegion, "Region of requests that are not signed for a region")
+	flag.Parse()
+
+	regions := fake.NewRegions()
+	srv := fake.NewServer(regions)
+	srv.DefaultRegion = *region
+	if *stateFile != "" {
+		err := loadState(regions, *stateFile)
+		if err != nil {
+			fmt.Printf("Error loading state from %s: %s\n", *stateFile, err)
+			os.Exit(1)
+		}
+		var mu sync.Mutex
+		srv.OnChange = func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			return saveState(regions, *stateFile)
+		}
+	}
+
+	log.Printf("Serving SSM on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, srv))
+}
+
+// loadState reads parameters from a file, if it exists
+func loadState(regions *fake.Regions, fn string) error {
+	f, err := os.Open(fn)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return regions.Load(f)
+}
+
+// saveState writes parameters to a temporary file and renames it, so the state file is never partially written
+func saveState(regions *fake.Regions, fn string) error {
+	f, err := os.CreateTemp(filepath.Dir(fn), filepath.Base(fn)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = regions.Save(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fn)
+}
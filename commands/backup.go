@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+// passphraseEnv may hold the passphrase of a backup, for use in scripts
+const passphraseEnv = "SSMSH_BACKUP_PASSPHRASE"
+
+const backupUsage string = `
+backup usage: backup [--kms-key key] path file
+Save every version, label, tag and policy of the parameters beneath a path to an encrypted file.
+The file is encrypted with a passphrase, read from $SSMSH_BACKUP_PASSPHRASE or prompted for,
+or with a data key from a KMS key in the region of the path.
+  --kms-key The ID, ARN or alias of a KMS key to encrypt the backup with
+Example:
+/> backup /prod prod-2026-10.ssmbak
+/> backup --kms-key alias/backups us-west-2:/prod prod-2026-10.ssmbak
+`
+
+func backup(c *ishell.Context) error {
+	args, kmsKey, err := checkOption(c.Args, "--kms-key")
+	if err != nil {
+		return invalid("%s", err)
+	}
+	if len(args) != 2 {
+		return &usageError{"Expected a path and a file", backupUsage}
+	}
+	path, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	key := parameterstore.BackupKey{KMSKeyID: kmsKey, KMSPath: path}
+	if kmsKey == "" {
+		key.Passphrase, err = newPassphrase()
+		if err != nil {
+			return err
+		}
+	}
+	b, err := ps.Backup(path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = ps.WriteBackup(&buf, b, key)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(args[1], buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+	printDiagnostic("Backed up %d parameters (%d versions) to %s\n", len(b.Parameters), backupVersions(b), args[1])
+	return nil
+}
+
+// newPassphrase reads and confirms the passphrase for a new backup
+func newPassphrase() ([]byte, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return []byte(p), nil
+	}
+	printDiagnostic("Passphrase: ")
+	p := shell.ReadPassword()
+	if p == "" {
+		return nil, invalid("a passphrase is required")
+	}
+	printDiagnostic("Confirm passphrase: ")
+	if shell.ReadPassword() != p {
+		return nil, invalid("passphrases do not match")
+	}
+	return []byte(p), nil
+}
+
+// backupVersions counts the parameter versions in a backup
+func backupVersions(b *parameterstore.Backup) (n int) {
+	for _, p := range b.Parameters {
+		n += len(p.History)
+	}
+	return n
+}
@@ -12,19 +12,22 @@ Example:
 /foo>
 `
 
-func cd(c *ishell.Context) {
-	var err error
+func cd(c *ishell.Context) error {
 	if len(c.Args) == 0 {
 		// noop
 	} else if len(c.Args) == 1 {
 		path := c.Args[0]
-		err = ps.SetCwd(parsePath(path))
+		parameterPath, err := parsePath(path)
 		if err != nil {
-			shell.Println("Error:", err)
-		} else {
-			setPrompt(ps.Cwd)
+			return err
 		}
+		err = ps.SetCwd(parameterPath)
+		if err != nil {
+			return err
+		}
+		setPrompt(ps.Cwd)
 	} else {
-		shell.Println("Incorrect number of arguments to cd command")
+		return &usageError{"Incorrect number of arguments to cd command", cdUsage}
 	}
+	return nil
 }
@@ -2,14 +2,20 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
 	"strings"
 
 	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/bwhaley/ssmsh/config"
 	"github.com/bwhaley/ssmsh/parameterstore"
 )
 
-type fn func(*ishell.Context)
+type fn func(*ishell.Context) error
 
 var (
 	shell *ishell.Shell
@@ -22,34 +28,102 @@ func Init(iShell *ishell.Shell, iPs *parameterstore.ParameterStore, iCfg *config
 	shell = iShell
 	ps = iPs
 	cfg = iCfg
+	registerCommand("backup", "save an encrypted copy of a path", backup, backupUsage)
 	registerCommand("cd", "change your relative location within the parameter store", cd, cdUsage)
 	registerCommand("cp", "copy source to dest", cp, cpUsage)
 	registerCommand("decrypt", "toggle parameter decryption", decrypt, decryptUsage)
+	registerCommand("diff", "compare parameters", diff, diffUsage)
+	registerCommand("dryrun", "toggle dry run mode", dryrun, dryrunUsage)
+	registerCommand("edit", "edit a parameter value in $EDITOR", edit, editUsage)
+	registerCommand("export", "export parameters to a file", export, exportUsage)
+	registerCommand("find", "find parameters by metadata", find, findUsage)
 	registerCommand("get", "get parameters", get, getUsage)
+	registerCommand("grep", "search parameter values", grep, grepUsage)
 	registerCommand("history", "get parameter history", history, historyUsage)
+	registerCommand("import", "import parameters from a file", importParameters, importUsage)
+	registerCommand("journal", "list the operations that can be undone", journal, journalUsage)
 	registerCommand("key", "set the KMS key", key, keyUsage)
+	registerCommand("label", "label a parameter version", label, labelUsage)
 	registerCommand("ls", "list parameters", ls, lsUsage)
 	registerCommand("mv", "move parameters", mv, mvUsage)
 	registerCommand("policy", "create named parameter policy", policy, policyUsage)
 	registerCommand("profile", "switch to a different AWS IAM profile", profile, profileUsage)
 	registerCommand("put", "set parameter", put, putUsage)
 	registerCommand("region", "change region", region, regionUsage)
+	registerCommand("restore", "restore parameters from a backup", restore, restoreUsage)
 	registerCommand("rm", "remove parameters", rm, rmUsage)
+	registerCommand("rollback", "restore a previous parameter version", rollback, rollbackUsage)
+	registerCommand("sync", "make a path mirror another path", syncPaths, syncUsage)
+	registerCommand("tag", "add tags to parameters", tag, tagUsage)
+	registerCommand("tags", "display parameter tags", tags, tagsUsage)
+	registerCommand("tree", "display the hierarchy beneath a path", tree, treeUsage)
+	registerCommand("undo", "revert the last operations", undo, undoUsage)
+	registerCommand("unlabel", "remove labels from a parameter version", unlabel, unlabelUsage)
+	registerCommand("untag", "remove tags from parameters", untag, untagUsage)
+	shell.NotFound(unknownCommand)
+	shell.CustomCompleter(completions)
 	setPrompt(parameterstore.Delimiter)
 }
 
-// registerCommand adds a command to the shell
+// unknownCommand reports input that does not name a command as invalid, so that scripts
+// exit with ExitUsage
+func unknownCommand(c *ishell.Context) {
+	c.Err(invalid("unknown command %s, try 'help'", c.Args[0]))
+}
+
+// mutatingCommands are the commands that modify parameters. They are refused in read-only mode
+// and their changes are recorded in the journal.
+var mutatingCommands = map[string]bool{
+	"cp":       true,
+	"edit":     true,
+	"import":   true,
+	"label":    true,
+	"mv":       true,
+	"put":      true,
+	"restore":  true,
+	"rm":       true,
+	"rollback": true,
+	"sync":     true,
+	"tag":      true,
+	"undo":     true,
+	"unlabel":  true,
+	"untag":    true,
+}
+
+// registerCommand adds a command to the shell. The changes made by a command are recorded as an operation in
+// the journal, any calls planned by the command in dry run mode are printed after it runs, and its error
+// is reported.
 func registerCommand(name string, helpText string, f fn, usageText string) {
 	shell.AddCmd(&ishell.Cmd{
 		Name:     name,
 		Help:     helpText,
 		LongHelp: usageText,
-		Func:     f,
+		Func: func(c *ishell.Context) {
+			if ps.ReadOnly && mutatingCommands[name] {
+				reportError(c, fmt.Errorf("%s is not allowed: %w", name, parameterstore.ErrReadOnly))
+				return
+			}
+			if mutatingCommands[name] {
+				ps.BeginOperation(operationDescription(name, c.Args))
+				defer ps.EndOperation()
+			}
+			err := f(c)
+			printPlan()
+			if err != nil {
+				reportError(c, err)
+			}
+		},
 	})
 }
 
+// readOnlyMarker is shown at the start of the prompt in read-only mode
+const readOnlyMarker = "[ro]"
+
 // setPrompt configures the shell prompt
 func setPrompt(prompt string) {
+	if ps.ReadOnly {
+		prompt = readOnlyMarker + prompt
+	}
 	shell.SetPrompt(prompt + ">")
 }
 
@@ -69,27 +143,134 @@ func checkRecursion(paths []string) ([]string, bool) {
 	return paths, false
 }
 
-// parsePath determines whether a path includes a region
-func parsePath(path string) (parameterPath parameterstore.ParameterPath) {
-	pathParts := strings.Split(path, ":")
-	switch len(pathParts) {
-	case 1:
-		parameterPath.Name = pathParts[0]
-		parameterPath.Region = ps.Region
-	case 2:
+// checkFlag searches a slice of strings for a flag such as --dry-run, removing every occurrence
+func checkFlag(args []string, flag string) ([]string, bool) {
+	var remaining []string
+	found := false
+	for _, a := range args {
+		if a == flag {
+			found = true
+		} else {
+			remaining = append(remaining, a)
+		}
+	}
+	return remaining, found
+}
+
+// checkOption searches a slice of strings for an option with a value, given as either
+// --option value or --option=value. Returns an error if the value is missing.
+func checkOption(args []string, option string) ([]string, string, error) {
+	var remaining []string
+	var value string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == option:
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("%s requires a value", option)
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], option+"="):
+			value = strings.TrimPrefix(args[i], option+"=")
+		default:
+			remaining = append(remaining, args[i])
+		}
+	}
+	return remaining, value, nil
+}
+
+// checkRedirect searches a slice of strings for an output redirection such as > file
+func checkRedirect(args []string) ([]string, string, error) {
+	var remaining []string
+	var file string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == ">":
+			if i+1 >= len(args) {
+				return nil, "", errors.New("expected a file name after >")
+			}
+			file = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], ">"):
+			file = strings.TrimPrefix(args[i], ">")
+		default:
+			remaining = append(remaining, args[i])
+		}
+	}
+	return remaining, file, nil
+}
+
+// expandPaths parses a list of paths, expanding any globs or regular expressions
+// to the parameters that match them
+func expandPaths(paths []string) (params []parameterstore.ParameterPath, err error) {
+	for _, p := range paths {
+		param, err := parsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		if !parameterstore.IsPattern(param.Name) {
+			params = append(params, param)
+			continue
+		}
+		matches, err := ps.Expand(param)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No parameters match %s", p)
+		}
+		params = append(params, matches...)
+	}
+	return params, nil
+}
+
+// parsePath determines whether a path includes a profile and/or a region, and initializes
+// a client for them. Any version or label selector remains part of the name,
+// e.g. prod@us-east-1:/app/url:3
+func parsePath(path string) (parameterPath parameterstore.ParameterPath, err error) {
+	parameterPath.Name = path
+	parameterPath.Region = ps.Region
+	profileParts := strings.SplitN(path, parameterstore.ProfileDelimiter, 2)
+	if len(profileParts) == 2 {
+		parameterPath.Profile = profileParts[0]
+		parameterPath.Name = profileParts[1]
+	}
+	pathParts := strings.SplitN(parameterPath.Name, ":", 2)
+	if len(pathParts) == 2 && isRegion(pathParts[0]) {
 		parameterPath.Region = pathParts[0]
 		parameterPath.Name = pathParts[1]
 	}
-	ps.InitClient(parameterPath.Region)
-	return parameterPath
+	if parameterPath.Profile != "" {
+		err = ps.InitProfileClient(parameterPath.Region, parameterPath.Profile)
+	} else {
+		err = ps.InitClient(parameterPath.Region)
+	}
+	return parameterPath, err
 }
 
-func groupByRegion(params []parameterstore.ParameterPath) map[string][]string {
-	paramsByRegion := make(map[string][]string)
+// absolutePath resolves a name relative to the current directory
+func absolutePath(name string) string {
+	if strings.HasPrefix(name, parameterstore.Delimiter) {
+		return name
+	}
+	return path.Join(ps.Cwd, name)
+}
+
+var regionRegexp = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// isRegion distinguishes a region prefix such as us-east-1: from a relative parameter name
+func isRegion(s string) bool {
+	return regionRegexp.MatchString(s)
+}
+
+// groupByClient groups parameter names by the key of the client for their region and profile
+func groupByClient(params []parameterstore.ParameterPath) map[string][]string {
+	paramsByClient := make(map[string][]string)
 	for _, p := range params {
-		paramsByRegion[p.Region] = append(paramsByRegion[p.Region], p.Name)
+		key := ps.ClientKey(p)
+		paramsByClient[key] = append(paramsByClient[key], p.Name)
 	}
-	return paramsByRegion
+	return paramsByClient
 }
 
 func trim(with []string) (without []string) {
@@ -99,20 +280,86 @@ func trim(with []string) (without []string) {
 	return without
 }
 
-func printResult(result interface{}) {
+// confirm asks the user a yes or no question, defaulting to no
+func confirm(question string) bool {
+	printDiagnostic("%s [y/N] ", question)
+	answer := strings.ToLower(strings.TrimSpace(shell.ReadLine()))
+	return answer == "y" || answer == "yes"
+}
+
+// maskedValue hides the values of SecureString parameters unless decryption is enabled
+func maskedValue(parameterType, value *string) string {
+	if aws.StringValue(parameterType) == ssm.ParameterTypeSecureString && !ps.Decrypt {
+		return secureStringMask
+	}
+	return aws.StringValue(value)
+}
+
+const secureStringMask = "********"
+
+// Output formats for results, set with output in the configuration file or the -output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputRaw  = "raw"
+)
+
+// OutputFormats are the valid output formats
+var OutputFormats = []string{OutputText, OutputJSON, OutputRaw}
+
+// ValidOutput returns true if an output format is supported
+func ValidOutput(output string) bool {
+	for _, o := range OutputFormats {
+		if output == o {
+			return true
+		}
+	}
+	return false
+}
+
+// printResult prints the result of a command in the configured output format
+func printResult(result interface{}) error {
 	switch cfg.Default.Output {
-	case "json":
-		printJSON(result)
+	case OutputJSON:
+		return printJSON(result)
+	case OutputRaw:
+		printRaw(result)
 	default:
 		shell.Printf("%+v\n", result)
 	}
+	return nil
 }
 
-func printJSON(result interface{}) {
+func printJSON(result interface{}) error {
 	resultJSON, err := json.MarshalIndent(result, "", "    ")
 	if err != nil {
-		shell.Println("Error with result: ", err)
-	} else {
-		shell.Println(string(resultJSON))
+		return fmt.Errorf("unable to format the result as JSON: %s", err)
+	}
+	shell.Println(string(resultJSON))
+	return nil
+}
+
+// printRaw prints only the values of parameters and versions, or the names of other results,
+// one per line and without formatting, for use in scripts
+func printRaw(result interface{}) {
+	switch r := result.(type) {
+	case []ssm.Parameter:
+		for _, p := range r {
+			shell.Println(aws.StringValue(p.Value))
+		}
+	case []ssm.ParameterHistory:
+		for _, h := range r {
+			shell.Println(aws.StringValue(h.Value))
+		}
+	case []ssm.ParameterMetadata:
+		for _, m := range r {
+			shell.Println(aws.StringValue(m.Name))
+		}
+	case []string:
+		for _, s := range r {
+			shell.Println(s)
+		}
+	default:
+		shell.Printf("%+v\n", result)
 	}
 }
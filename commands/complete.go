@@ -0,0 +1,190 @@
+package commands
+
+import (
+	spath "path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const policiesPrefix = "policies=["
+
+// pathCommands are the commands that accept parameter paths as arguments
+var pathCommands = map[string]bool{
+	"backup":   true,
+	"cd":       true,
+	"cp":       true,
+	"diff":     true,
+	"edit":     true,
+	"export":   true,
+	"find":     true,
+	"get":      true,
+	"grep":     true,
+	"history":  true,
+	"label":    true,
+	"ls":       true,
+	"mv":       true,
+	"rm":       true,
+	"rollback": true,
+	"sync":     true,
+	"tag":      true,
+	"tags":     true,
+	"tree":     true,
+	"unlabel":  true,
+	"untag":    true,
+}
+
+// completer implements readline.AutoCompleter for commands, parameter paths, regions and policy names
+type completer struct {
+	mu    sync.Mutex
+	cache map[string][]string // Path listings for the session, keyed by [profile@]region:path
+}
+
+var completions = &completer{cache: make(map[string][]string)}
+
+// resetCompletions discards cached path listings, e.g. after parameters are added or removed
+func resetCompletions() {
+	completions.mu.Lock()
+	defer completions.mu.Unlock()
+	completions.cache = make(map[string][]string)
+}
+
+// Do returns the candidates for the word under the cursor and the length of that word
+func (pc *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
+	words := strings.Fields(string(line[:pos]))
+	prefix := ""
+	if len(words) > 0 && !unicode.IsSpace(line[pos-1]) {
+		prefix = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(prefix, policiesPrefix):
+		// Also matches the multiline put prompt, where there is no command word
+		candidates = policyCandidates(prefix)
+	case len(words) == 0:
+		candidates = commandCandidates()
+	case words[0] == "region" && len(words) == 1:
+		candidates = regionCandidates()
+	case pathCommands[words[0]]:
+		candidates = pc.pathCandidates(prefix)
+	}
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			newLine = append(newLine, []rune(strings.TrimPrefix(c, prefix)))
+		}
+	}
+	if len(newLine) == 1 && prefix != "" && len(newLine[0]) == 0 {
+		newLine = [][]rune{[]rune(" ")}
+	}
+	return newLine, len([]rune(prefix))
+}
+
+// commandCandidates returns the names of all registered commands
+func commandCandidates() (names []string) {
+	for _, c := range shell.Cmds() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// regionCandidates returns the regions where SSM is available
+func regionCandidates() (regions []string) {
+	for _, p := range endpoints.DefaultPartitions() {
+		service, ok := p.Services()[ssm.EndpointsID]
+		if !ok {
+			continue
+		}
+		for id := range service.Regions() {
+			// Leave out pseudo regions such as fips-us-east-1
+			if isRegion(id) {
+				regions = append(regions, id)
+			}
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// policyCandidates completes the last policy name in a policies=[a,b,... list
+func policyCandidates(word string) (candidates []string) {
+	i := strings.LastIndexAny(word, "[,")
+	head := word[:i+1]
+	var names []string
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		candidates = append(candidates, head+name)
+	}
+	return candidates
+}
+
+// pathCandidates completes a relative or absolute path, optionally prefixed with profile@ and region:
+func (pc *completer) pathCandidates(word string) (candidates []string) {
+	var locationPrefix string
+	path := word
+	if i := strings.Index(path, parameterstore.ProfileDelimiter); i >= 0 {
+		locationPrefix, path = path[:i+1], path[i+1:]
+	}
+	if i := strings.Index(path, ":"); i >= 0 && isRegion(path[:i]) {
+		locationPrefix, path = locationPrefix+path[:i+1], path[i+1:]
+	} else if (path != "" || locationPrefix != "") && !strings.ContainsAny(path, ":"+parameterstore.Delimiter) {
+		// The word may be the start of a region: prefix
+		for _, r := range regionCandidates() {
+			candidates = append(candidates, locationPrefix+r+":")
+		}
+	}
+	dir := path[:strings.LastIndex(path, parameterstore.Delimiter)+1]
+
+	parameterPath, err := parsePath(locationPrefix + dir)
+	if err != nil {
+		return candidates
+	}
+	if !strings.HasPrefix(parameterPath.Name, parameterstore.Delimiter) {
+		parameterPath.Name = spath.Join(ps.Cwd, parameterPath.Name)
+	}
+	parameterPath.Name = spath.Clean(parameterPath.Name)
+
+	for _, entry := range pc.list(parameterPath) {
+		if strings.HasPrefix(entry, parameterstore.Delimiter) {
+			// The directory itself is also a parameter
+			continue
+		}
+		candidates = append(candidates, locationPrefix+dir+entry)
+	}
+	return candidates
+}
+
+// list returns the top level entries under a path, using the cache when possible
+func (pc *completer) list(path parameterstore.ParameterPath) []string {
+	key := ps.ClientKey(path) + ":" + path.Name
+	pc.mu.Lock()
+	entries, ok := pc.cache[key]
+	pc.mu.Unlock()
+	if ok {
+		return entries
+	}
+
+	lr := make(chan parameterstore.ListResult)
+	quit := make(chan bool)
+	go ps.List(path, false, lr, quit)
+	result := <-lr
+	if result.Error != nil {
+		return nil
+	}
+	sort.Strings(result.Result)
+
+	pc.mu.Lock()
+	pc.cache[key] = result.Result
+	pc.mu.Unlock()
+	return result.Result
+}
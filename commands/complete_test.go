@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/bwhaley/ssmsh/config"
+	"github.com/bwhaley/ssmsh/parameterstore"
+	"github.com/bwhaley/ssmsh/parameterstore/fake"
+)
+
+// initCompletionTest initializes the commands with a fake backend that has a few parameters
+func initCompletionTest(t *testing.T) *fake.Regions {
+	regions := fake.NewRegions()
+	params := map[string][]string{
+		"us-west-2": {"/House/Stark/AryaStark", "/House/Stark/JonSnow", "/House/Lannister/TyrionLannister", "/Dragons"},
+		"us-east-1": {"/House/Tully/EdmureTully"},
+	}
+	for region, names := range params {
+		for _, name := range names {
+			_, err := regions.Store(region).PutParameter(&ssm.PutParameterInput{
+				Name:  aws.String(name),
+				Value: aws.String("Westeros"),
+				Type:  aws.String("String"),
+			})
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+	}
+
+	var p parameterstore.ParameterStore
+	p.Region = "us-west-2"
+	p.Type = parameterstore.DefaultParameterType
+	p.NewClient = regions.Client
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	s := ishell.New()
+	s.SetOut(&bytes.Buffer{})
+	Init(s, &p, &config.Config{})
+	resetCompletions()
+	t.Cleanup(resetCompletions)
+	return regions
+}
+
+// complete returns the words that the completer would complete the end of a line to
+func complete(line string) (words []string) {
+	suffixes, length := completions.Do([]rune(line), len([]rune(line)))
+	prefix := string([]rune(line)[len([]rune(line))-length:])
+	for _, s := range suffixes {
+		words = append(words, prefix+string(s))
+	}
+	sort.Strings(words)
+	return words
+}
+
+func TestComplete(t *testing.T) {
+	initCompletionTest(t)
+	policies = map[string]parameterPolicies{"expire": {}, "notify": {}}
+	t.Cleanup(func() { policies = map[string]parameterPolicies{} })
+
+	cases := []struct {
+		Line     string
+		Cwd      string
+		Expected []string
+	}{
+		{"hist", "/", []string{"history"}},
+		{"get /", "/", []string{"/Dragons", "/House/"}},
+		{"get /House/", "/", []string{"/House/Lannister/", "/House/Stark/"}},
+		{"get /House/St", "/", []string{"/House/Stark/"}},
+		{"cp /House/Stark/AryaStark /House/Stark/J", "/", []string{"/House/Stark/JonSnow"}},
+		{"get /House/Stark/JonSnow", "/", []string{"/House/Stark/JonSnow "}},
+		{"ls Hou", "/", []string{"House/"}},
+		{"ls ", "/House", []string{"Lannister/", "Stark/"}},
+		{"get us-east-1:/House/", "/", []string{"us-east-1:/House/Tully/"}},
+		{"get us-west-", "/", []string{"us-west-1:", "us-west-2:"}},
+		{"get prod@us-west-", "/", []string{"prod@us-west-1:", "prod@us-west-2:"}},
+		{"region us-west-", "/", []string{"us-west-1", "us-west-2"}},
+		{"region fips", "/", nil},
+		{"region us-west-2 us-", "/", nil},
+		{"put policies=[", "/", []string{"policies=[expire", "policies=[notify"}},
+		{"put policies=[expire,n", "/", []string{"policies=[expire,notify"}},
+		{"policies=[ex", "/", []string{"policies=[expire"}},
+		{"journal /", "/", nil},
+	}
+	for _, c := range cases {
+		ps.Cwd = c.Cwd
+		got := complete(c.Line)
+		if strings.Join(got, " ") != strings.Join(c.Expected, " ") {
+			t.Errorf("expected %q to complete to %q, got %q", c.Line, c.Expected, got)
+		}
+	}
+}
+
+func TestRegionCandidates(t *testing.T) {
+	regions := regionCandidates()
+	if !sort.StringsAreSorted(regions) {
+		t.Fatal("expected regions to be sorted")
+	}
+	var found bool
+	for _, r := range regions {
+		if !isRegion(r) {
+			t.Fatalf("unexpected region %s", r)
+		}
+		found = found || r == "us-gov-west-1"
+	}
+	if !found {
+		t.Fatal("expected regions from every partition")
+	}
+}
+
+func TestCompletionCache(t *testing.T) {
+	regions := initCompletionTest(t)
+	expected := []string{"/House/Stark/AryaStark", "/House/Stark/JonSnow"}
+	if got := complete("get /House/Stark/"); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	// Changes made outside the shell are not seen until the cache is reset
+	_, err := regions.Store("us-west-2").PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/House/Stark/BranStark"),
+		Value: aws.String("Three-eyed raven"),
+		Type:  aws.String("String"),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if got := complete("get /House/Stark/"); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected the cached %q, got %q", expected, got)
+	}
+
+	err = shell.Process("put", "name=/House/Stark/SansaStark", "value=Lady", "type=String")
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	expected = []string{"/House/Stark/AryaStark", "/House/Stark/BranStark", "/House/Stark/JonSnow", "/House/Stark/SansaStark"}
+	if got := complete("get /House/Stark/"); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %q after a put, got %q", expected, got)
+	}
+
+	err = shell.Process("rm", "-f", "/House/Stark/AryaStark")
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	expected = expected[1:]
+	if got := complete("get /House/Stark/"); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %q after an rm, got %q", expected, got)
+	}
+
+	err = shell.Process("region", "us-east-1")
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	expected = []string{"/House/Tully/"}
+	if got := complete("get /House/"); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %q after changing region, got %q", expected, got)
+	}
+}
@@ -2,23 +2,70 @@ package commands
 
 import (
 	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
 )
 
 const cpUsage string = `
-cp usage: cp [-rR] src dest
-Copy a parameter from src to dest.
+cp usage: cp [-rR] [-f] [--no-tags] [--no-tier] [--no-policies] src[:version|:label] dest
+Copy a parameter from src to dest. The type, data type, tier, policies and tags
+of the source are carried over unless otherwise requested. The KMS key is carried
+over only within a region and profile. A version or label of
+the source parameter may be selected. When src is a glob or re: regular expression,
+the matching parameters are copied beneath dest, keeping their names relative to
+the literal part of the pattern, e.g. cp /dev/*/url /backup copies /dev/api/url to
+/backup/api/url. When overwrite is enabled and dest exists, the number of parameters
+to copy is shown for confirmation.
   -r Copy parameters recursively
+  -f Do not ask for confirmation, except for protected paths
+  --no-tags Do not copy tags
+  --no-tier Do not copy the tier. Implies --no-policies
+  --no-policies Do not copy parameter policies
 `
 
-func cp(c *ishell.Context) {
-	paths, recurse := checkRecursion(c.Args)
+func cp(c *ishell.Context) error {
+	args, force := checkFlag(c.Args, "-f")
+	args, opts := checkCopyOptions(args)
+	paths, recurse := checkRecursion(args)
 	if len(paths) != 2 {
-		shell.Println("Expected src and dst")
-		shell.Println(cpUsage)
-		return
+		return &usageError{"Expected src and dst", cpUsage}
 	}
-	err := ps.Copy(parsePath(paths[0]), parsePath(paths[1]), recurse)
+	src, err := parsePath(paths[0])
 	if err != nil {
-		shell.Println(err)
+		return err
 	}
+	dst, err := parsePath(paths[1])
+	if err != nil {
+		return err
+	}
+	var sources []parameterstore.ParameterPath
+	if parameterstore.IsPattern(src.Name) {
+		sources, err = ps.Expand(src)
+	} else {
+		sources, err = ps.Resolve([]parameterstore.ParameterPath{src}, recurse)
+	}
+	if err != nil {
+		return err
+	}
+	overwrite := ps.Overwrite && ps.Exists(dst)
+	question := "Copy " + countNoun(len(sources)) + " to " + dst.Name + ", overwriting existing parameters?"
+	err = confirmChange(question, []string{absolutePath(dst.Name)}, force || !overwrite)
+	if err != nil {
+		return err
+	}
+	if parameterstore.IsPattern(src.Name) {
+		err = ps.CopyPattern(src, dst, opts)
+	} else {
+		err = ps.Copy(src, dst, recurse, opts)
+	}
+	resetCompletions()
+	return err
+}
+
+// checkCopyOptions removes the flags that control which parameter attributes are copied
+func checkCopyOptions(args []string) ([]string, parameterstore.CopyOptions) {
+	var opts parameterstore.CopyOptions
+	args, opts.SkipTags = checkFlag(args, "--no-tags")
+	args, opts.SkipTier = checkFlag(args, "--no-tier")
+	args, opts.SkipPolicies = checkFlag(args, "--no-policies")
+	return args, opts
 }
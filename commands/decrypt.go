@@ -14,12 +14,11 @@ Toggles decryption of SecureString parameter values. Default is false.
 const decryptError = "value for decrypt must be boolean"
 
 // decrypt determines parameter decryption for SecureString values
-func decrypt(c *ishell.Context) {
+func decrypt(c *ishell.Context) error {
 	if len(c.Args) == 1 {
 		v, err := strconv.ParseBool(c.Args[0])
 		if err != nil {
-			shell.Println(decryptError)
-			return
+			return invalid(decryptError)
 		}
 
 		switch v {
@@ -28,10 +27,11 @@ func decrypt(c *ishell.Context) {
 		case false:
 			ps.Decrypt = false
 		default:
-			shell.Println(decryptError)
+			return invalid(decryptError)
 		}
 	} else if len(c.Args) > 1 {
-		shell.Println(decryptError)
+		return invalid(decryptError)
 	}
-	shell.Println("Decrypt is", ps.Decrypt)
+	printDiagnostic("Decrypt is %t\n", ps.Decrypt)
+	return nil
 }
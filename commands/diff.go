@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const diffUsage string = `
+diff usage: diff [-r] src dst
+            diff parameter
+Compare two parameters, two versions of a parameter, or the parameters beneath two paths.
+Reports parameters that exist on only one side and differing values and metadata.
+SecureString values are masked unless decryption is enabled. With a single parameter,
+compares the latest version with the previous version.
+  -r Compare paths recursively
+Example:
+/> diff -r /staging/app /prod/app
+/> diff us-east-1:/app/url eu-west-1:/app/url
+/> diff /app/url:2 /app/url:prod-approved
+`
+
+func diff(c *ishell.Context) error {
+	paths, recurse := checkRecursion(c.Args)
+	var src, dst string
+	switch len(paths) {
+	case 1:
+		var err error
+		src, dst, err = previousVersions(paths[0])
+		if err != nil {
+			return err
+		}
+	case 2:
+		src, dst = paths[0], paths[1]
+	default:
+		return usage(diffUsage)
+	}
+	srcPath, err := parsePath(src)
+	if err != nil {
+		return err
+	}
+	dstPath, err := parsePath(dst)
+	if err != nil {
+		return err
+	}
+	diffs, err := ps.Diff(srcPath, dstPath, recurse)
+	if err != nil {
+		return err
+	}
+	if cfg.Default.Output == OutputJSON {
+		return printResult(maskDiffs(diffs))
+	}
+	printDiff(src, dst, diffs)
+	return nil
+}
+
+// previousVersions returns selectors for the previous and latest versions of a parameter
+func previousVersions(param string) (string, string, error) {
+	parameterPath, err := parsePath(param)
+	if err != nil {
+		return "", "", err
+	}
+	history, err := ps.GetHistory(parameterPath)
+	if err != nil {
+		return "", "", err
+	}
+	if len(history) < 2 {
+		return "", "", notFound("%s has no previous version", param)
+	}
+	previous := aws.Int64Value(history[len(history)-2].Version)
+	latest := aws.Int64Value(history[len(history)-1].Version)
+	return fmt.Sprintf("%s:%d", param, previous), fmt.Sprintf("%s:%d", param, latest), nil
+}
+
+// printDiff prints differences in a format similar to a unified diff
+func printDiff(src, dst string, diffs []parameterstore.ParameterDiff) {
+	if len(diffs) == 0 {
+		return
+	}
+	shell.Println("--- " + src)
+	shell.Println("+++ " + dst)
+	for _, d := range maskDiffs(diffs) {
+		switch {
+		case d.Dst == nil:
+			shell.Println("-" + d.Name)
+		case d.Src == nil:
+			shell.Println("+" + d.Name)
+		default:
+			shell.Printf("@@ %s @@\n", d.Name)
+			for _, change := range d.Changes {
+				shell.Printf("-%s: %s\n", change.Field, change.Src)
+				shell.Printf("+%s: %s\n", change.Field, change.Dst)
+			}
+		}
+	}
+}
+
+// maskDiffs returns a copy of diffs with SecureString values masked unless decryption is enabled
+func maskDiffs(diffs []parameterstore.ParameterDiff) (masked []parameterstore.ParameterDiff) {
+	for _, d := range diffs {
+		if d.Src != nil {
+			src := *d.Src
+			src.Value = aws.String(maskedValue(src.Type, src.Value))
+			d.Src = &src
+		}
+		if d.Dst != nil {
+			dst := *d.Dst
+			dst.Value = aws.String(maskedValue(dst.Type, dst.Value))
+			d.Dst = &dst
+		}
+		var changes []parameterstore.FieldChange
+		for _, change := range d.Changes {
+			if change.Field == "Value" {
+				change.Src = aws.StringValue(d.Src.Value)
+				change.Dst = aws.StringValue(d.Dst.Value)
+			}
+			changes = append(changes, change)
+		}
+		d.Changes = changes
+		masked = append(masked, d)
+	}
+	return masked
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const dryrunUsage string = `
+dryrun usage: dryrun [on|off]
+Toggles dry run mode, or turns it on or off. In dry run mode, commands print the calls that
+would modify parameters as a plan instead of making them. Default is off, or on when ssmsh
+is started with --dry-run.
+Example:
+/> dryrun on
+/> rm -r /prod/app
+`
+
+const dryrunError = "value for dryrun must be on or off"
+
+// dryrun determines whether modifications are planned rather than made
+func dryrun(c *ishell.Context) error {
+	switch len(c.Args) {
+	case 0:
+		ps.DryRun = !ps.DryRun
+	case 1:
+		switch strings.ToLower(c.Args[0]) {
+		case "on", "true":
+			ps.DryRun = true
+		case "off", "false":
+			ps.DryRun = false
+		default:
+			return invalid(dryrunError)
+		}
+	default:
+		return invalid(dryrunError)
+	}
+	if ps.DryRun {
+		printDiagnostic("Dry run is on\n")
+	} else {
+		printDiagnostic("Dry run is off\n")
+	}
+	return nil
+}
+
+// printPlan prints the calls recorded by the last command in dry run mode as a diagnostic
+func printPlan() {
+	plan := ps.TakePlan()
+	if len(plan) == 0 {
+		return
+	}
+	printDiagnostic("Dry run, no changes made. Plan:\n")
+	for _, call := range plan {
+		printDiagnostic("  %s %s\n", call.Client, describeCall(call))
+	}
+}
+
+// describeCall summarizes a planned call, masking SecureString values unless decryption is enabled
+func describeCall(call parameterstore.PlannedCall) string {
+	switch in := call.Input.(type) {
+	case *ssm.PutParameterInput:
+		s := fmt.Sprintf("put %s type=%s value=%s", aws.StringValue(in.Name), aws.StringValue(in.Type),
+			maskedValue(in.Type, in.Value))
+		if in.KeyId != nil {
+			s += " key=" + aws.StringValue(in.KeyId)
+		}
+		if in.Tier != nil {
+			s += " tier=" + aws.StringValue(in.Tier)
+		}
+		if aws.BoolValue(in.Overwrite) {
+			s += " overwrite=true"
+		}
+		return s
+	case *ssm.DeleteParametersInput:
+		return "delete " + strings.Join(aws.StringValueSlice(in.Names), " ")
+	case *ssm.AddTagsToResourceInput:
+		var tags []string
+		for _, t := range in.Tags {
+			tags = append(tags, aws.StringValue(t.Key)+"="+aws.StringValue(t.Value))
+		}
+		return fmt.Sprintf("tag %s %s", aws.StringValue(in.ResourceId), strings.Join(tags, ","))
+	case *ssm.RemoveTagsFromResourceInput:
+		return fmt.Sprintf("untag %s %s", aws.StringValue(in.ResourceId), strings.Join(aws.StringValueSlice(in.TagKeys), ","))
+	case *ssm.LabelParameterVersionInput:
+		return fmt.Sprintf("label %s %s", versionedName(in.Name, in.ParameterVersion), strings.Join(aws.StringValueSlice(in.Labels), ","))
+	case *ssm.UnlabelParameterVersionInput:
+		return fmt.Sprintf("unlabel %s %s", versionedName(in.Name, in.ParameterVersion), strings.Join(aws.StringValueSlice(in.Labels), ","))
+	default:
+		return fmt.Sprintf("%s %+v", call.Operation, call.Input)
+	}
+}
+
+// versionedName appends a version selector to a name when the version is known
+func versionedName(name *string, version *int64) string {
+	if aws.Int64Value(version) == 0 {
+		return aws.StringValue(name)
+	}
+	return fmt.Sprintf("%s%s%d", aws.StringValue(name), parameterstore.SelectorDelimiter, aws.Int64Value(version))
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/mattn/go-shellwords"
+)
+
+const editUsage string = `
+edit usage: edit [-y] parameter
+Open the decrypted value of a parameter in $VISUAL or $EDITOR, which defaults to vi. When
+the editor exits, the changes are shown and, once confirmed, put as a new version with the
+same type, key, description, pattern, tier and policies. Nothing is put if the value is unchanged.
+  -y Do not ask for confirmation
+Example:
+/> edit /dev/app/tls/certificate
+/> edit /dev/app/config.json
+`
+
+func edit(c *ishell.Context) error {
+	args, yes := checkFlag(c.Args, "-y")
+	if len(args) != 1 {
+		return usage(editUsage)
+	}
+	param, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	current, err := ps.EditTarget(param)
+	if err != nil {
+		return err
+	}
+	before := aws.StringValue(current.Value)
+	after, err := editValue(before)
+	if err != nil {
+		return err
+	}
+	if after == before {
+		printDiagnostic("No changes to %s\n", aws.StringValue(current.Name))
+		return nil
+	}
+	printDiagnostic("--- %s\n", aws.StringValue(current.Name))
+	printDiagnostic("+++ %s\n", aws.StringValue(current.Name))
+	for _, line := range diffLines(before, after) {
+		printDiagnostic("%s\n", line)
+	}
+	err = confirmChange("Save changes?", []string{aws.StringValue(current.Name)}, yes)
+	if err != nil {
+		return err
+	}
+	resp, err := ps.Edit(param, current, after)
+	if err != nil {
+		return err
+	}
+	printPutVersion(aws.StringValue(current.Name), resp)
+	return nil
+}
+
+// editValue writes a value to a temporary file readable only by the user, opens it in
+// an editor and returns the edited value. A trailing newline added by the editor is removed.
+func editValue(value string) (string, error) {
+	f, err := os.CreateTemp("", "ssmsh-edit-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	editor, err := editorCommand()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	result := string(edited)
+	if !strings.HasSuffix(value, "\n") {
+		result = strings.TrimSuffix(strings.TrimSuffix(result, "\n"), "\r")
+	}
+	return result, nil
+}
+
+// editorCommand returns the user's preferred editor and any arguments, e.g. code --wait
+func editorCommand() ([]string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	words, err := shellwords.Parse(editor)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("no editor configured, set $EDITOR")
+	}
+	return words, nil
+}
+
+// diffLines compares two values line by line, returning the lines of a unified diff
+// without hunk headers: removed lines are prefixed with -, added lines with + and
+// unchanged lines with a space
+func diffLines(before, after string) (lines []string) {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	return lines
+}
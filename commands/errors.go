@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+// Exit codes for commands that fail when run from a script or the command line
+const (
+	ExitError        = 1 // An error not covered by the other codes
+	ExitUsage        = 2 // Invalid arguments, or a request that failed validation
+	ExitNotFound     = 3 // A parameter, version, label or path does not exist
+	ExitAccessDenied = 4 // Missing or expired credentials, insufficient permissions, or a change refused by ssmsh
+	ExitThrottled    = 5 // Requests were throttled by AWS
+)
+
+// Stderr is where errors, usage and prompts are written when commands are run from a script
+// or the command line, so that only results are written to stdout. The interactive shell
+// writes everything to the terminal.
+var Stderr io.Writer = os.Stderr
+
+// usageError is returned by a command called with invalid arguments
+type usageError struct {
+	msg   string
+	usage string
+}
+
+func (e *usageError) Error() string {
+	if e.msg == "" {
+		return "invalid arguments"
+	}
+	return e.msg
+}
+
+// usage returns a usageError for a command's usage
+func usage(text string) error {
+	return &usageError{usage: text}
+}
+
+// deniedError is returned when ssmsh refuses a change, e.g. to a protected path
+type deniedError struct {
+	msg string
+}
+
+func (e *deniedError) Error() string {
+	return e.msg
+}
+
+// denied creates a deniedError with a formatted message
+func denied(format string, args ...interface{}) error {
+	return &deniedError{msg: fmt.Sprintf(format, args...)}
+}
+
+// invalidError is returned for invalid input to a command, such as a malformed option value
+type invalidError struct {
+	msg string
+}
+
+func (e *invalidError) Error() string {
+	return e.msg
+}
+
+// invalid creates an invalidError with a formatted message
+func invalid(format string, args ...interface{}) error {
+	return &invalidError{msg: fmt.Sprintf(format, args...)}
+}
+
+// notFoundError is returned when the parameters named in a command do not exist
+type notFoundError struct {
+	msg string
+}
+
+func (e *notFoundError) Error() string {
+	return e.msg
+}
+
+// notFound creates a notFoundError with a formatted message
+func notFound(format string, args ...interface{}) error {
+	return &notFoundError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errCancelled is returned when the user declines to confirm a change
+var errCancelled = errors.New("cancelled")
+
+// reportError makes an error the result of a command. The shell prints it, and in scripts
+// it is returned by shell.Process so that the exit code reflects it. The usage of a command
+// called with invalid arguments is printed, and a declined confirmation is not an error
+// worth printing in the shell.
+func reportError(c *ishell.Context, err error) {
+	var uerr *usageError
+	if errors.As(err, &uerr) {
+		if shell.Active() {
+			if uerr.msg != "" {
+				shell.Println(uerr.msg)
+			}
+			shell.Println(uerr.usage)
+			return
+		}
+		printDiagnostic("%s\n", uerr.usage)
+	}
+	if shell.Active() && err == errCancelled {
+		return
+	}
+	c.Err(err)
+}
+
+// printDiagnostic writes a message that is not a result: to the terminal in the shell, or to Stderr in scripts
+func printDiagnostic(format string, args ...interface{}) {
+	if shell.Active() {
+		shell.Printf(format, args...)
+		return
+	}
+	fmt.Fprintf(Stderr, format, args...)
+}
+
+// awsErrorExitCodes map the codes of errors returned by AWS to exit codes
+var awsErrorExitCodes = map[string]int{
+	ssm.ErrCodeParameterNotFound:                    ExitNotFound,
+	ssm.ErrCodeParameterVersionNotFound:             ExitNotFound,
+	ssm.ErrCodeInvalidResourceId:                    ExitNotFound,
+	kms.ErrCodeNotFoundException:                    ExitNotFound,
+	"AccessDeniedException":                         ExitAccessDenied,
+	"UnrecognizedClientException":                   ExitAccessDenied,
+	"InvalidClientTokenId":                          ExitAccessDenied,
+	"InvalidSignatureException":                     ExitAccessDenied,
+	"ExpiredToken":                                  ExitAccessDenied,
+	"ExpiredTokenException":                         ExitAccessDenied,
+	"NoCredentialProviders":                         ExitAccessDenied,
+	kms.ErrCodeDisabledException:                    ExitAccessDenied,
+	"ValidationException":                           ExitUsage,
+	request.InvalidParameterErrCode:                 ExitUsage,
+	ssm.ErrCodeParameterPatternMismatchException:    ExitUsage,
+	ssm.ErrCodeInvalidAllowedPatternException:       ExitUsage,
+	ssm.ErrCodeInvalidFilterKey:                     ExitUsage,
+	ssm.ErrCodeInvalidFilterOption:                  ExitUsage,
+	ssm.ErrCodeInvalidFilterValue:                   ExitUsage,
+	ssm.ErrCodeInvalidKeyId:                         ExitUsage,
+	ssm.ErrCodeInvalidPolicyTypeException:           ExitUsage,
+	ssm.ErrCodeInvalidPolicyAttributeException:      ExitUsage,
+	ssm.ErrCodeIncompatiblePolicyException:          ExitUsage,
+	ssm.ErrCodeHierarchyLevelLimitExceededException: ExitUsage,
+	ssm.ErrCodeHierarchyTypeMismatchException:       ExitUsage,
+	ssm.ErrCodeUnsupportedParameterType:             ExitUsage,
+	ssm.ErrCodeTooManyUpdates:                       ExitThrottled,
+}
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var uerr *usageError
+	var ierr *invalidError
+	var derr *deniedError
+	var nerr *notFoundError
+	var pnerr *parameterstore.NotFoundError
+	var perr *parameterstore.ProtectedError
+	var aerr awserr.Error
+	switch {
+	case errors.As(err, &uerr), errors.As(err, &ierr):
+		return ExitUsage
+	case errors.As(err, &derr), errors.As(err, &perr), errors.Is(err, parameterstore.ErrReadOnly):
+		return ExitAccessDenied
+	case errors.As(err, &nerr), errors.As(err, &pnerr):
+		return ExitNotFound
+	case request.IsErrorThrottle(err):
+		return ExitThrottled
+	case errors.As(err, &aerr):
+		if code, ok := awsErrorExitCodes[aerr.Code()]; ok {
+			return code
+		}
+	}
+	return ExitError
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"os"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const exportUsage string = `
+export usage: export [-r] [--format json|yaml|dotenv|sh|tfvars] path [> file]
+Export the parameters beneath a path. SecureString values are always decrypted.
+JSON and YAML nest the hierarchy and include the type, description, KMS key, tier and
+policies of each parameter. dotenv and sh convert names relative to the path to
+UPPER_SNAKE variables, tfvars to lower_snake variables.
+Files are created with mode 0600.
+  -r Export recursively
+  --format The output format (default json)
+Example:
+/> export -r /dev/app > app.json
+/> export -r --format dotenv /dev/app > .env
+`
+
+func export(c *ishell.Context) error {
+	args, recurse := checkRecursion(c.Args)
+	args, file, err := checkRedirect(args)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	args, format, err := checkOption(args, "--format")
+	if err != nil {
+		return invalid("%s", err)
+	}
+	if format == "" {
+		format = parameterstore.FormatJSON
+	}
+	if len(args) != 1 {
+		return &usageError{"Expected a single path", exportUsage}
+	}
+	path, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	params, err := ps.Export(path, recurse)
+	if err != nil {
+		return err
+	}
+	data, err := parameterstore.MarshalParameters(params, format)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	if file == "" {
+		shell.Print(string(data))
+		return nil
+	}
+	err = os.WriteFile(file, data, 0600)
+	if err != nil {
+		return err
+	}
+	printDiagnostic("Exported %d parameters to %s\n", len(params), strings.TrimSpace(file))
+	return nil
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const findUsage string = `
+find usage: find [path] [-l] [-name glob] [-type type] [-key key] [-tier tier] [-modified-since time]
+                 [-modified-by user] [-label label] [-tag key=value] ...
+Find the parameters beneath a path, which defaults to the current directory, that meet all
+of the given criteria. Prints the matching names, or their full metadata with -l.
+  -l Print the metadata of each parameter
+  -name A glob matched against the last element of the name, e.g. *password*
+  -type String, StringList or SecureString
+  -key The KMS key ID, ARN or alias, e.g. alias/aws/ssm
+  -tier Standard, Advanced or Intelligent-Tiering
+  -modified-since A duration such as 30m, 24h or 7d, or a date such as 2026-10-01
+  -modified-by The ARN or user name of the last modifier
+  -label A label attached to any version
+  -tag A tag that must be present. May be repeated
+Example:
+/> find /prod -type SecureString -key alias/aws/ssm
+/> find /prod -modified-since 24h -l
+/> find / -name '*password*' -tag Team=payments
+`
+
+func find(c *ishell.Context) error {
+	args, long := checkFlag(c.Args, "-l")
+	var opts parameterstore.FindOptions
+	var path string
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			if path != "" {
+				return &usageError{"Expected a single path", findUsage}
+			}
+			path = args[i]
+			continue
+		}
+		if i+1 >= len(args) {
+			return invalid("%s requires a value", args[i])
+		}
+		err := setFindOption(&opts, args[i], args[i+1])
+		if err != nil {
+			return invalid("%s", err)
+		}
+		i++
+	}
+	if path == "" {
+		path = ps.Cwd
+	}
+	parameterPath, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	found, err := ps.Find(parameterPath, opts)
+	if err != nil {
+		return err
+	}
+	if long {
+		return printResult(found)
+	}
+	var names []string
+	for _, m := range found {
+		names = append(names, aws.StringValue(m.Name))
+	}
+	if cfg.Default.Output == OutputJSON {
+		return printResult(names)
+	}
+	for _, n := range names {
+		shell.Println(n)
+	}
+	return nil
+}
+
+// setFindOption sets a find criterion from a command line option
+func setFindOption(opts *parameterstore.FindOptions, option, value string) (err error) {
+	switch option {
+	case "-name":
+		opts.Name = value
+	case "-type":
+		opts.Type = value
+	case "-key":
+		opts.KeyID = value
+	case "-tier":
+		opts.Tier = value
+	case "-label":
+		opts.Label = value
+	case "-modified-by":
+		opts.ModifiedBy = value
+	case "-modified-since":
+		opts.ModifiedSince, err = parseSince(value, time.Now())
+	case "-tag":
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("tag %q must be in the form key=value", value)
+		}
+		opts.Tags = append(opts.Tags, ssm.Tag{Key: aws.String(kv[0]), Value: aws.String(kv[1])})
+	default:
+		return fmt.Errorf("unknown option %s", option)
+	}
+	return err
+}
+
+// parseSince converts a duration before now, such as 24h or 7d, or a date to a time
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse %s as a duration or date", s)
+}
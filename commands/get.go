@@ -1,34 +1,62 @@
 package commands
 
 import (
+	"strings"
+
 	"github.com/abiosoft/ishell"
-	"github.com/bwhaley/ssmsh/parameterstore"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
 )
 
 const getUsage string = `
-get usage: get parameter ...
-Get one or more parameters.
+get usage: get parameter[:version|:label] ...
+Get one or more parameters. Select a specific version by number or label.
+Parameters may be matched with globs (*, **, ?, {a,b}) or a re: regular expression.
+Example:
+/> get /dev/app/url /dev/app/url:3 us-west-2:/dev/app/url:prod-approved
+/> get /prod/**/password
+/> get 're:/prod/(api|web)/db/.*'
 `
 
 // Get parameters
-func get(c *ishell.Context) {
-	if len(c.Args) >= 1 {
-		var params []parameterstore.ParameterPath
-		for _, p := range c.Args {
-			params = append(params, parsePath(p))
+func get(c *ishell.Context) error {
+	if len(c.Args) == 0 {
+		return usage(getUsage)
+	}
+	params, err := expandPaths(c.Args)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for key, params := range groupByClient(params) {
+		resp, err := ps.Get(params, key)
+		if err != nil {
+			return err
 		}
-		paramsByRegion := groupByRegion(params)
-		for region, params := range paramsByRegion {
-			resp, err := ps.Get(params, region)
+		if len(resp) >= 1 {
+			err = printResult(resp)
 			if err != nil {
-				shell.Println("Error: ", err)
-			} else {
-				if len(resp) >= 1 {
-					printResult(resp)
-				}
+				return err
 			}
 		}
-	} else {
-		shell.Println(getUsage)
+		missing = append(missing, missingParameters(params, resp)...)
+	}
+	if len(missing) > 0 {
+		return notFound("Parameters not found: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingParameters returns the names, with any version or label selector, that are not among the parameters returned by Get
+func missingParameters(names []string, found []ssm.Parameter) (missing []string) {
+	returned := make(map[string]bool)
+	for _, p := range found {
+		returned[aws.StringValue(p.Name)+aws.StringValue(p.Selector)] = true
+	}
+	for _, n := range names {
+		if !returned[absolutePath(n)] {
+			missing = append(missing, n)
+		}
 	}
+	return missing
 }
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"regexp"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/fatih/color"
+)
+
+const grepUsage string = `
+grep usage: grep [-r] [-i] [-l] pattern path ...
+Search parameter values for a regular expression and print each match as name: value,
+with the matching text highlighted. Paths are searched one level deep unless -r is given,
+and may be parameters, globs or re: regular expressions. SecureString values are only
+searched when decryption is enabled.
+  -r Search paths recursively
+  -i Ignore case
+  -l Print only the names of matching parameters
+Example:
+/> grep -r db.example.com /prod
+/> grep -r -i -l 'password=' /dev /test
+`
+
+var highlight = color.New(color.FgRed, color.Bold).SprintFunc()
+
+func grep(c *ishell.Context) error {
+	args, recurse := checkRecursion(c.Args)
+	args, ignoreCase := checkFlag(args, "-i")
+	args, namesOnly := checkFlag(args, "-l")
+	if len(args) < 2 {
+		return &usageError{"Expected a pattern and at least one path", grepUsage}
+	}
+	expr := args[0]
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	params, err := expandPaths(args[1:])
+	if err != nil {
+		return err
+	}
+	matches, err := ps.Grep(re, params, recurse)
+	if err != nil {
+		return err
+	}
+	if cfg.Default.Output == OutputJSON {
+		if namesOnly {
+			var names []string
+			for _, m := range matches {
+				names = append(names, aws.StringValue(m.Name))
+			}
+			return printResult(names)
+		}
+		return printResult(matches)
+	}
+	for _, m := range matches {
+		if namesOnly {
+			shell.Println(aws.StringValue(m.Name))
+			continue
+		}
+		value := re.ReplaceAllStringFunc(aws.StringValue(m.Value), func(s string) string {
+			return highlight(s)
+		})
+		shell.Printf("%s: %s\n", aws.StringValue(m.Name), value)
+	}
+	return nil
+}
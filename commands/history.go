@@ -6,21 +6,30 @@ import (
 
 const (
 	historyUsage = `
-usage: history parameter
-Display modification the history of a parameter.
+usage: history parameter[:version|:label]
+Display modification the history of a parameter, or only the selected version.
+Globs or a re: regular expression display the history of each matching parameter.
 `
 )
 
 // history prints the history of a parameter
-func history(c *ishell.Context) {
+func history(c *ishell.Context) error {
 	if len(c.Args) != 1 {
-		shell.Println(historyUsage)
-		return
+		return usage(historyUsage)
 	}
-	resp, err := ps.GetHistory(parsePath(c.Args[0]))
+	params, err := expandPaths(c.Args)
 	if err != nil {
-		shell.Println("Error: ", err)
-	} else {
-		printResult(resp)
+		return err
 	}
+	for _, p := range params {
+		resp, err := ps.GetHistory(p)
+		if err != nil {
+			return err
+		}
+		err = printResult(resp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
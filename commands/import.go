@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const importUsage string = `
+import usage: import [-f] [--prefix path] [--type type] [--format json|yaml|dotenv] [--overwrite] [--dry-run] file
+Create parameters from a JSON, YAML or dotenv file, such as one written by export. The format is
+detected from the file extension unless given. Names in the file are relative to the prefix,
+which defaults to the current directory. Entries may set a type, key, tier, description,
+policies and tags. dotenv variables keep their names, e.g. DB_URL becomes /prefix/DB_URL.
+  -f Do not ask for confirmation before overwriting parameters, except for protected paths
+  --prefix The path beneath which to create the parameters
+  --type The type of entries that do not specify one (default SecureString, or the type set in .ssmshrc)
+  --overwrite Update existing parameters that differ
+  --dry-run Report what would change without writing anything
+Example:
+/> import --prefix /dev/app app.json
+/> import --prefix us-west-2:/dev/app --type SecureString --overwrite .env
+`
+
+func importParameters(c *ishell.Context) error {
+	args, force := checkFlag(c.Args, "-f")
+	args, overwrite := checkFlag(args, "--overwrite")
+	args, dryRun := checkFlag(args, "--dry-run")
+	var prefix, paramType, format string
+	var err error
+	for option, value := range map[string]*string{"--prefix": &prefix, "--type": &paramType, "--format": &format} {
+		args, *value, err = checkOption(args, option)
+		if err != nil {
+			return invalid("%s", err)
+		}
+	}
+	if len(args) != 1 {
+		return &usageError{"Expected a single file", importUsage}
+	}
+	if format == "" {
+		format = detectFormat(args[0])
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	params, err := parameterstore.UnmarshalParameters(data, format)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	if prefix == "" {
+		prefix = ps.Cwd
+	}
+	prefixPath, err := parsePath(prefix)
+	if err != nil {
+		return err
+	}
+	opts := parameterstore.ImportOptions{
+		Type:      paramType,
+		Overwrite: overwrite,
+		DryRun:    dryRun,
+	}
+	if !dryRun {
+		// Find the parameters that would change so that overwrites and protected paths can be confirmed
+		planOpts := opts
+		planOpts.DryRun = true
+		plan, err := ps.Import(params, prefixPath, planOpts)
+		if err != nil {
+			return err
+		}
+		names := append(plan.Created, plan.Updated...)
+		question := "Import " + countNoun(len(names)) + "?"
+		if len(plan.Updated) > 0 {
+			question = "Import " + countNoun(len(names)) + ", overwriting " + countNoun(len(plan.Updated)) + "?"
+		}
+		err = confirmChange(question, names, force || len(plan.Updated) == 0)
+		if err != nil {
+			return err
+		}
+	}
+	result, err := ps.Import(params, prefixPath, opts)
+	if !dryRun {
+		resetCompletions()
+	}
+	printImportResult(result)
+	return err
+}
+
+// detectFormat chooses an import format from a file extension
+func detectFormat(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		return parameterstore.FormatJSON
+	case ".yaml", ".yml":
+		return parameterstore.FormatYAML
+	}
+	return parameterstore.FormatDotenv
+}
+
+// printImportResult prints the changed parameters and a summary of an import as diagnostics
+func printImportResult(result parameterstore.ImportResult) {
+	for _, name := range result.Created {
+		printDiagnostic("create %s\n", name)
+	}
+	for _, name := range result.Updated {
+		printDiagnostic("update %s\n", name)
+	}
+	for _, name := range result.Skipped {
+		printDiagnostic("skip %s (exists, use --overwrite to update)\n", name)
+	}
+	printDiagnostic("%d created, %d updated, %d unchanged, %d skipped\n",
+		len(result.Created), len(result.Updated), len(result.Unchanged), len(result.Skipped))
+}
@@ -1,12 +1,10 @@
 package commands
 
 import (
-	"fmt"
-
 	"github.com/abiosoft/ishell"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/kms"
-	saws "github.com/bwhaley/ssmsh/aws"
+	"github.com/bwhaley/ssmsh/parameterstore"
 )
 
 const keyUsage string = `
@@ -14,19 +12,19 @@ key ARN|ID
 Set the KMS key ARN (or ID) to use with SecureString parameters
 `
 
-func key(c *ishell.Context) {
+func key(c *ishell.Context) error {
 	if len(c.Args) != 1 {
-		shell.Println(keyUsage)
-		return
-	}
-	if err := checkKey(c.Args[0]); err != nil {
-		shell.Println(err)
+		return usage(keyUsage)
 	}
 	ps.Key = c.Args[0]
+	return checkKey(c.Args[0])
 }
 
 func checkKey(key string) (err error) {
-	client := kms.New(saws.NewSession(ps.Region, ps.Profile))
+	client, err := ps.KMSClient(parameterstore.ParameterPath{Region: ps.Region})
+	if err != nil {
+		return err
+	}
 	input := kms.ListKeysInput{}
 	for {
 		resp, err := client.ListKeys(&input)
@@ -43,5 +41,5 @@ func checkKey(key string) (err error) {
 		}
 		input.Marker = resp.NextMarker
 	}
-	return fmt.Errorf("key %s not found in this region", key)
+	return notFound("key %s not found in this region", key)
 }
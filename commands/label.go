@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"github.com/abiosoft/ishell"
+)
+
+const labelUsage string = `
+label usage: label parameter[:version|:label] label ...
+Attach labels to a version of a parameter. The latest version is labeled
+unless a version (or a label already on a version) is selected.
+Example:
+/> label /dev/app/url prod-approved
+/> label /dev/app/url:3 rollback
+`
+
+func label(c *ishell.Context) error {
+	if len(c.Args) < 2 {
+		return usage(labelUsage)
+	}
+	param, err := parsePath(c.Args[0])
+	if err != nil {
+		return err
+	}
+	return ps.LabelParameterVersion(param, c.Args[1:])
+}
@@ -1,53 +1,228 @@
 package commands
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"os/signal"
+	spath "path"
 	"sort"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/bwhaley/ssmsh/parameterstore"
 )
 
 const lsUsage string = `
-ls -[r|R] path ...
-Print the parameters in one or more paths.
+ls -[r|R] [-l] [-t] [-h] path ...
+Print the parameters in one or more paths. Paths that are globs or re: regular
+expressions print the matching parameters.
 -[r|R] List parameters recursively
+-l Long listing with the type, tier, version, last modified date, last modified user and KMS key
+-t Sort by last modified date, newest first
+-h Print last modified dates relative to now, e.g. 3h ago
+Flags may be combined, e.g. -lt
+Example:
+/> ls /dev/*/db/*
+/> ls 're:/dev/app-[0-9]+/.*'
+/> ls -lth /dev/app
 `
 
-func ls(c *ishell.Context) {
+// lsFlags are the flags accepted by ls
+type lsFlags struct {
+	recurse, long, byTime, relative bool
+}
+
+func ls(c *ishell.Context) error {
 	var err error
 	var pathList []string
-	paths, recurse := checkRecursion(c.Args)
+	paths, flags, err := checkListFlags(c.Args)
+	if err != nil {
+		return &usageError{err.Error(), lsUsage}
+	}
 	// If no paths were provided, list the current directory
 	if len(paths) == 0 {
 		paths = append(paths, ps.Cwd)
 	}
 	for _, p := range paths {
-		pathList, err = list(p, recurse)
+		pathList, err = list(p, flags.recurse)
 		if err != nil {
-			shell.Println("Error: ", err)
-			return
+			return err
 		}
 		if len(paths) > 1 && len(pathList) != 0 {
 			shell.Println(p + ":")
 		}
 		sort.Strings(pathList)
+		if flags.long || flags.byTime {
+			err = listLong(p, pathList, flags)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 		for _, r := range pathList {
 			shell.Printf("%+s\n", r)
 		}
 	}
+	return nil
+}
+
+// checkListFlags separates the flags given to ls, which may be combined as in -lt, from the paths
+func checkListFlags(args []string) ([]string, lsFlags, error) {
+	var paths []string
+	var flags lsFlags
+	for _, a := range args {
+		if len(a) < 2 || !strings.HasPrefix(a, "-") {
+			paths = append(paths, a)
+			continue
+		}
+		for _, f := range a[1:] {
+			switch f {
+			case 'r', 'R':
+				flags.recurse = true
+			case 'l':
+				flags.long = true
+			case 't':
+				flags.byTime = true
+			case 'h':
+				flags.relative = true
+			default:
+				return nil, flags, fmt.Errorf("unknown flag -%c", f)
+			}
+		}
+	}
+	return paths, flags, nil
+}
+
+// listLong prints the entries of a listing with their metadata, fetched in batches with DescribeParameters
+func listLong(path string, entries []string, flags lsFlags) error {
+	parameterPath, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	dir := absolutePath(parameterPath.Name)
+	var params []parameterstore.ParameterPath
+	names := make(map[string]string)
+	for _, e := range entries {
+		if strings.HasSuffix(e, parameterstore.Delimiter) {
+			continue
+		}
+		p := parameterPath
+		p.Name = e
+		if !strings.HasPrefix(e, parameterstore.Delimiter) {
+			p.Name = spath.Join(dir, e)
+		}
+		names[e] = p.Name
+		params = append(params, p)
+	}
+	metadata, err := ps.Describe(params)
+	if err != nil {
+		return err
+	}
+	if flags.byTime {
+		// Newest first, followed by directories in name order
+		modified := func(e string) time.Time {
+			return aws.TimeValue(metadata[names[e]].LastModifiedDate)
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return modified(entries[i]).After(modified(entries[j]))
+		})
+	}
+	if !flags.long {
+		for _, e := range entries {
+			shell.Println(e)
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	now := time.Now()
+	for _, e := range entries {
+		m, ok := metadata[names[e]]
+		if !ok {
+			fmt.Fprintf(w, "-\t-\t-\t-\t-\t-\t%s\n", e)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			aws.StringValue(m.Type),
+			aws.StringValue(m.Tier),
+			aws.Int64Value(m.Version),
+			modifiedDate(aws.TimeValue(m.LastModifiedDate), now, flags.relative),
+			lastModifiedUser(m),
+			orDash(aws.StringValue(m.KeyId)),
+			e)
+	}
+	w.Flush()
+	shell.Print(buf.String())
+	return nil
+}
+
+// modifiedDate formats a last modified date, optionally relative to now
+func modifiedDate(t, now time.Time, relative bool) string {
+	if !relative {
+		return t.Local().Format("2006-01-02 15:04:05")
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+	}
+}
+
+// lastModifiedUser shortens the ARN of the last modifier to its resource, e.g. user/alice
+func lastModifiedUser(m ssm.ParameterMetadata) string {
+	arn := aws.StringValue(m.LastModifiedUser)
+	if i := strings.LastIndex(arn, ":"); i >= 0 {
+		arn = arn[i+1:]
+	}
+	return orDash(arn)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
 
 func list(path string, recurse bool) ([]string, error) {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT)
 
-	quit := make(chan bool)
-	lr := make(chan parameterstore.ListResult)
+	// Both channels are buffered so that neither side blocks when the other has
+	// finished, e.g. on an interrupt while a pattern is expanded
+	quit := make(chan bool, 1)
+	lr := make(chan parameterstore.ListResult, 1)
 	go func() {
-		parameterPath := parsePath(path)
+		parameterPath, err := parsePath(path)
+		if err != nil {
+			lr <- parameterstore.ListResult{Error: err}
+			return
+		}
+		if parameterstore.IsPattern(parameterPath.Name) {
+			matches, err := ps.Expand(parameterPath)
+			var names []string
+			for _, m := range matches {
+				names = append(names, m.Name)
+			}
+			lr <- parameterstore.ListResult{Result: names, Error: err}
+			return
+		}
 		ps.List(parameterPath, recurse, lr, quit)
 	}()
 
@@ -61,7 +236,6 @@ func list(path string, recurse bool) ([]string, error) {
 		quit <- true
 		signal.Stop(sigs)
 		close(sigs)
-		close(lr)
 		return nil, nil
 	}
 }
@@ -2,16 +2,44 @@ package commands
 
 import (
 	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
 )
 
 const mvUsage string = `
-mv usage: mv src dst
-Move parameter from src to dst.
+mv usage: mv [-f] [--no-tags] [--no-tier] [--no-policies] src dst
+Move parameter from src to dst. The type, data type, tier, policies and tags
+of the source are carried over unless otherwise requested. The number of
+parameters to move is shown for confirmation.
+  -f Do not ask for confirmation, except for protected paths
+  --no-tags Do not move tags
+  --no-tier Do not move the tier. Implies --no-policies
+  --no-policies Do not move parameter policies
 `
 
-func mv(c *ishell.Context) {
-	err := ps.Move(parsePath(c.Args[0]), parsePath(c.Args[1]))
+func mv(c *ishell.Context) error {
+	args, force := checkFlag(c.Args, "-f")
+	paths, opts := checkCopyOptions(args)
+	if len(paths) != 2 {
+		return &usageError{"Expected src and dst", mvUsage}
+	}
+	src, err := parsePath(paths[0])
+	if err != nil {
+		return err
+	}
+	dst, err := parsePath(paths[1])
+	if err != nil {
+		return err
+	}
+	resolved, err := ps.Resolve([]parameterstore.ParameterPath{src}, true)
+	if err != nil {
+		return err
+	}
+	names := append(parameterNames(resolved), absolutePath(dst.Name))
+	err = confirmChange("Move "+countNoun(len(resolved))+" to "+dst.Name+"?", names, force)
 	if err != nil {
-		shell.Println(err)
+		return err
 	}
+	err = ps.Move(src, dst, opts)
+	resetCompletions()
+	return err
 }
@@ -79,20 +79,17 @@ type NoChangeNotificationAttributes struct {
 	Unit  string
 }
 
-func policy(c *ishell.Context) {
+func policy(c *ishell.Context) error {
 	if len(c.Args) == 1 {
-		err := printPolicy(c.Args[0])
-		if err != nil {
-			shell.Printf("Error: %s\n", err)
-		}
+		return printPolicy(c.Args[0])
 	} else if len(c.Args) > 1 {
 		err := createPolicy(c.Args[0], c.Args[1:])
 		if err != nil {
-			shell.Printf("Error: %s\n", err)
+			return invalid("%s", err)
 		}
-	} else {
-		shell.Println(policyUsage)
+		return nil
 	}
+	return usage(policyUsage)
 }
 
 func printPolicy(policyName string) (err error) {
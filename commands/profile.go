@@ -7,13 +7,19 @@ profile name
 Switch to the specified profile as listed in the .aws config or credentials file.
 `
 
-func profile(c *ishell.Context) {
+func profile(c *ishell.Context) error {
 	if len(c.Args) == 0 {
 		if ps.Profile != "" {
 			shell.Println(ps.Profile)
 		}
 	} else if len(c.Args) == 1 {
-		ps.Profile = c.Args[0]
-		ps.InitClient(ps.Region)
+		err := ps.SetProfile(c.Args[0])
+		if err != nil {
+			return err
+		}
+		resetCompletions()
+	} else {
+		return usage(profileUsage)
 	}
+	return nil
 }
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/abiosoft/readline"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+// interactive is true when commands are read from a terminal, in which case changes are confirmed
+var interactive = isTerminal(os.Stdin)
+
+// isTerminal reports whether a file is a terminal rather than a pipe, a regular file or a device such as /dev/null
+func isTerminal(f *os.File) bool {
+	return readline.IsTerminal(int(f.Fd()))
+}
+
+// confirmChange asks the user to confirm a change to the named parameters unless it is forced
+// or dry run mode is on, and returns errCancelled if they decline. When the shell is not
+// interactive, unforced changes are refused rather than assumed to be confirmed.
+// Changes to paths protected by the protect section of the configuration file are refused, or
+// must be confirmed by typing the protected path even when forced.
+func confirmChange(question string, names []string, force bool) error {
+	err := ps.CheckProtected(names...)
+	if err != nil {
+		return err
+	}
+	if ps.DryRun {
+		return nil
+	}
+	protected := make(map[string]bool)
+	for _, prefix := range cfg.Protect.Confirm {
+		for _, n := range names {
+			if parameterstore.IsBeneath(n, prefix) {
+				protected[prefix] = true
+			}
+		}
+	}
+	if len(protected) > 0 {
+		var prefixes []string
+		for prefix := range protected {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		printDiagnostic("%s\n", question)
+		for _, prefix := range prefixes {
+			if !interactive {
+				return denied("%s is protected and changes must be confirmed interactively", prefix)
+			}
+			printDiagnostic("%s is protected. Type %s to confirm: ", prefix, prefix)
+			if strings.TrimSpace(shell.ReadLine()) != prefix {
+				printDiagnostic("Aborted\n")
+				return errCancelled
+			}
+		}
+		return nil
+	}
+	if force {
+		return nil
+	}
+	if !interactive {
+		return invalid("%s cannot be confirmed when not interactive, use -f (or -y for rollback and edit) to go ahead", strings.TrimSuffix(question, "?"))
+	}
+	if confirm(question) {
+		return nil
+	}
+	return errCancelled
+}
+
+// parameterNames returns the names of parameters
+func parameterNames(params []parameterstore.ParameterPath) (names []string) {
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// countNoun formats a count of parameters, e.g. 1 parameter or 3 parameters
+func countNoun(n int) string {
+	if n == 1 {
+		return "1 parameter"
+	}
+	return fmt.Sprintf("%d parameters", n)
+}
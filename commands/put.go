@@ -15,9 +15,10 @@ import (
 )
 
 // TODO Inline syntax
-const putUsage string = `usage: put <newline>
+const putUsage string = `usage: put [-f] <newline>
 Create or update parameters. Enter one option per line, ending with a blank line, or all
-options inline. All fields except name, value, and type are optional.
+options inline. All fields except name, value, and type are optional. Overwriting an
+existing parameter asks for confirmation unless -f is given.
 Example of inline put:
 /> put name=/House/Targaryen/Daenerys value="Queen" type="String" description="Mother of dragons"
 Example of multiline put:
@@ -31,6 +32,7 @@ Example of multiline put:
 ... pattern=[A-z]+
 ... tier=advanced
 ... policies=[policy1, policy2]
+... tags=[Environment=prod,Team=finance]
 ...
 />
 Use the policy command to create named policy objects. Tier defaults to standard unless policies are defined.
@@ -39,45 +41,60 @@ Use the policy command to create named policy objects. Tier defaults to standard
 var putParamInput ssm.PutParameterInput
 var putParamRegion string
 
+// putOptionErr is the error from the last option read by putOptions
+var putOptionErr error
+
 // Add or update parameters
-func put(c *ishell.Context) {
+func put(c *ishell.Context) error {
 	var err error
 	var resp *ssm.PutParameterOutput
 
 	putParamInput = ssm.PutParameterInput{}
 	err = setDefaults(&putParamInput)
 	if err != nil {
-		shell.Println(err)
-		return
+		return invalid("%s", err)
 	}
 
 	// Read args for values
-	var r bool
-	if len(c.Args) == 0 {
-		r = multiLinePut()
+	args, force := checkFlag(c.Args, "-f")
+	if len(args) == 0 {
+		err = multiLinePut()
 	} else {
-		r = inlinePut(c.Args)
+		err = inlinePut(args)
 	}
-	if !r {
-		return
+	if err != nil {
+		return err
 	}
 
 	if putParamInput.Name == nil ||
 		putParamInput.Value == nil ||
 		putParamInput.Type == nil {
-		shell.Println("Error: name, type and value are required.")
-		return
+		return invalid("name, type and value are required.")
 	}
 
-	resp, err = ps.Put(&putParamInput, putParamRegion)
+	param := parameterstore.ParameterPath{Name: aws.StringValue(putParamInput.Name), Region: putParamRegion}
+	overwrite := aws.BoolValue(putParamInput.Overwrite) && ps.Exists(param)
+	err = confirmChange("Overwrite "+param.Name+"?", []string{param.Name}, force || !overwrite)
 	if err != nil {
-		shell.Println("Error: ", err)
-	} else {
-		version := strconv.Itoa(int(aws.Int64Value(resp.Version)))
-		if version != "" {
-			shell.Println("Put " + aws.StringValue(putParamInput.Name) + " version " + version)
-		}
+		return err
+	}
+
+	resp, err = ps.Put(&putParamInput, ps.ClientKey(param))
+	resetCompletions()
+	if err != nil {
+		return err
+	}
+	printPutVersion(aws.StringValue(putParamInput.Name), resp)
+	return nil
+}
+
+// printPutVersion reports the version created by a put. Nothing is put in dry run mode, so
+// there is no version to report.
+func printPutVersion(name string, resp *ssm.PutParameterOutput) {
+	if ps.DryRun {
+		return
 	}
+	shell.Println("Put " + name + " version " + strconv.FormatInt(aws.Int64Value(resp.Version), 10))
 }
 
 // setDefaults sets parameter settings according to the defaults
@@ -95,48 +112,55 @@ func setDefaults(param *ssm.PutParameterInput) (err error) {
 	return nil
 }
 
-func multiLinePut() bool {
+func multiLinePut() error {
 	// Set the prompt explicitly rather than use SetMultiPrompt
 	// due to the unexpected 2nd line behavior
 	shell.SetPrompt("... ")
 	defer setPrompt(ps.Cwd)
 
-	shell.Println("Input options. End with a blank line.")
+	printDiagnostic("Input options. End with a blank line.\n")
+	putOptionErr = nil
 	str := shell.ReadMultiLinesFunc(putOptions)
+	if putOptionErr != nil {
+		return putOptionErr
+	}
 	if str == "" {
-		shell.Println("multiline input ended in empty string")
-		return false
+		return invalid("multiline input ended in empty string")
 	}
-	return true
+	return nil
 }
 
-func inlinePut(options []string) bool {
+func inlinePut(options []string) error {
 	for _, p := range options {
-		if !putOptions(p) {
-			return false
+		if p == "" {
+			continue
+		}
+		err := putOption(p)
+		if err != nil {
+			return err
 		}
 	}
-	return true
+	return nil
 }
 
+// putOptions reads an option of a multiline put, returning false at the end of the input or after an invalid option
 func putOptions(s string) bool {
 	if s == "" {
 		return false
 	}
+	putOptionErr = putOption(s)
+	return putOptionErr == nil
+}
+
+// putOption sets a put option given as field=value
+func putOption(s string) error {
 	paramOption := strings.Split(s, "=")
 	if len(paramOption) < 2 {
-		shell.Println("invalid input")
-		shell.Println(putUsage)
-		return false
+		return &usageError{"invalid input " + s, putUsage}
 	}
 	field := strings.ToLower(paramOption[0])
 	val := strings.Join(paramOption[1:], "=") // Handles the case where a value has an "=" character
-	err := validate(field, val)
-	if err != nil {
-		shell.Println(err)
-		return false
-	}
-	return true
+	return validate(field, val)
 }
 
 func validate(f, v string) (err error) {
@@ -151,14 +175,14 @@ func validate(f, v string) (err error) {
 		"region":      validateRegion,
 		"tier":        validateTier,
 		"policies":    validatePolicies,
+		"tags":        validateTags,
 	}
 	if validator, ok := m[strings.ToLower(f)]; ok {
 		err = validator(v)
 		if err != nil {
 			// A validator failed so we need to reset the parameter input to an empty state
 			putParamInput = ssm.PutParameterInput{}
-			shell.Println(putUsage)
-			return err
+			return &usageError{err.Error(), putUsage}
 		}
 	}
 	return nil
@@ -221,8 +245,7 @@ func validatePattern(s string) (err error) {
 func validateOverwrite(s string) (err error) {
 	overwrite, err := strconv.ParseBool(s)
 	if err != nil {
-		shell.Println("overwrite must be true or false")
-		return err
+		return errors.New("overwrite must be true or false")
 	}
 	putParamInput.SetOverwrite(overwrite)
 	return nil
@@ -277,3 +300,12 @@ func validatePolicies(s string) (err error) {
 	putParamInput.Tier = aws.String(AdvancedTier)
 	return nil
 }
+
+func validateTags(s string) (err error) {
+	tags, err := parseTags(s)
+	if err != nil {
+		return err
+	}
+	putParamInput.Tags = tags
+	return nil
+}
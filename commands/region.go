@@ -11,16 +11,17 @@ Example:
 region us-west-2
 `
 
-func region(c *ishell.Context) {
+func region(c *ishell.Context) error {
 	if len(c.Args) == 0 {
 		if ps.Region != "" {
 			shell.Println(ps.Region)
 		}
 	} else if len(c.Args) == 1 {
 		ps.Region = c.Args[0]
-		err := ps.NewParameterStore(true)
-		if err != nil {
-			shell.Printf("Error: %s", err)
-		}
+		resetCompletions()
+		return ps.NewParameterStore(true)
+	} else {
+		return usage(regionUsage)
 	}
+	return nil
 }
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"os"
+	"path"
+
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const restoreUsage string = `
+restore usage: restore [-f] [--overwrite] [--to path] file
+Recreate the parameters in a backup, putting each version in order and reattaching labels
+and tags. Parameters are restored to their original path, region and profile unless --to is
+given. Parameters that already exist are only restored with --overwrite, which puts the latest
+version from the backup over them. KMS keys are not restored to another region or profile.
+Passphrase protected backups read the passphrase from $SSMSH_BACKUP_PASSPHRASE or prompt for it.
+  -f Do not ask for confirmation, except for protected paths
+  --overwrite Put the latest version over parameters that already exist
+  --to The path beneath which to restore the parameters
+Example:
+/> restore prod-2026-10.ssmbak
+/> restore --to us-west-2:/prod-restore prod-2026-10.ssmbak
+`
+
+func restore(c *ishell.Context) error {
+	args, force := checkFlag(c.Args, "-f")
+	args, overwrite := checkFlag(args, "--overwrite")
+	args, to, err := checkOption(args, "--to")
+	if err != nil {
+		return &usageError{err.Error(), restoreUsage}
+	}
+	if len(args) != 1 {
+		return &usageError{"Expected a single file", restoreUsage}
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := ps.ReadBackup(f, backupPassphrase)
+	if err != nil {
+		return err
+	}
+	toPath, err := restorePath(b, to)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		to = b.Path
+	}
+	existing, err := ps.RestoreConflicts(b, toPath)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && !overwrite {
+		return invalid("restoring would overwrite %s beneath %s, use --overwrite to put the latest version from the backup over existing parameters",
+			countNoun(len(existing)), to)
+	}
+	var names []string
+	for _, p := range b.Parameters {
+		names = append(names, path.Join(absolutePath(toPath.Name), p.Name))
+	}
+	question := "Restore " + countNoun(len(names)) + " to " + to + "?"
+	if len(existing) > 0 {
+		question = "Restore " + countNoun(len(names)) + " to " + to + ", overwriting " + countNoun(len(existing)) + "?"
+	}
+	err = confirmChange(question, names, force)
+	if err != nil {
+		return err
+	}
+	versions, err := ps.Restore(b, toPath, overwrite)
+	resetCompletions()
+	if err != nil {
+		return err
+	}
+	printDiagnostic("Restored %d parameters (%d versions) to %s\n", len(b.Parameters), versions, to)
+	return nil
+}
+
+// restorePath returns the path to restore a backup to: the path given with --to, or else the
+// path, region and profile that were backed up
+func restorePath(b *parameterstore.Backup, to string) (parameterstore.ParameterPath, error) {
+	if to != "" {
+		return parsePath(to)
+	}
+	source := b.Source()
+	if source.Region == "" {
+		source.Region = ps.Region
+	}
+	if source.Profile == "" {
+		source.Profile = ps.Profile
+	}
+	return source, ps.InitProfileClient(source.Region, source.Profile)
+}
+
+// backupPassphrase reads the passphrase of an existing backup
+func backupPassphrase() ([]byte, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return []byte(p), nil
+	}
+	printDiagnostic("Passphrase: ")
+	return []byte(shell.ReadPassword()), nil
+}
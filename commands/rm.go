@@ -2,32 +2,40 @@ package commands
 
 import (
 	"github.com/abiosoft/ishell"
-	"github.com/bwhaley/ssmsh/parameterstore"
 )
 
 const rmUsage string = `
-usage: rm -[r|R] parameter ...
+usage: rm -[r|R] [-f] parameter ...
 Remove parameters. Separate multiple parameters with spaces. Parameters may be
-absolute or relative.
+absolute or relative, and may be matched with globs or a re: regular expression.
+The number of parameters to remove is shown for confirmation.
 -[r|R] Remove parameters recursively
+-f Do not ask for confirmation, except for protected paths
 Example usage:
 /> rm /foo/bar /baz
 /> rm -R /foo/
+/> rm -f /dev/*/tmp-*
 `
 
-func rm(c *ishell.Context) {
-	var err error
-	var parameterPaths []parameterstore.ParameterPath
-	paths, recurse := checkRecursion(c.Args)
-	if len(paths) >= 1 {
-		for _, p := range paths {
-			parameterPaths = append(parameterPaths, parsePath(p))
-		}
-		err = ps.Remove(parameterPaths, recurse)
-		if err != nil {
-			shell.Println("Error: ", err)
-		}
-	} else {
-		shell.Println(rmUsage, err)
+func rm(c *ishell.Context) error {
+	args, force := checkFlag(c.Args, "-f")
+	paths, recurse := checkRecursion(args)
+	if len(paths) < 1 {
+		return usage(rmUsage)
 	}
+	parameterPaths, err := expandPaths(paths)
+	if err != nil {
+		return err
+	}
+	resolved, err := ps.Resolve(parameterPaths, recurse)
+	if err != nil {
+		return err
+	}
+	err = confirmChange("Remove "+countNoun(len(resolved))+"?", parameterNames(resolved), force)
+	if err != nil {
+		return err
+	}
+	err = ps.Delete(resolved)
+	resetCompletions()
+	return err
 }
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const rollbackUsage string = `
+rollback usage: rollback [-y] parameter [version|label]
+Restore a previous version of a parameter by putting its value, type, key, description,
+pattern, tier and policies as a new version. Defaults to the version prior to the latest.
+  -y Do not ask for confirmation
+Example:
+/> rollback /dev/app/url
+/> rollback -y /dev/app/url 3
+/> rollback /dev/app/url prod-approved
+`
+
+func rollback(c *ishell.Context) error {
+	args, yes := checkFlag(c.Args, "-y")
+	if len(args) < 1 || len(args) > 2 {
+		return usage(rollbackUsage)
+	}
+	param, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		param.Name = param.Name + ":" + args[1]
+	}
+	latest, target, err := ps.RollbackTarget(param)
+	if err != nil {
+		return err
+	}
+	printDiagnostic("Rolling back %s from version %d to version %d\n",
+		aws.StringValue(latest.Name), aws.Int64Value(latest.Version), aws.Int64Value(target.Version))
+	printRollbackSummary(latest, target)
+	err = confirmChange("Continue?", []string{aws.StringValue(latest.Name)}, yes)
+	if err != nil {
+		return err
+	}
+	resp, err := ps.Rollback(param, latest, target)
+	if err != nil {
+		return err
+	}
+	printPutVersion(aws.StringValue(latest.Name), resp)
+	return nil
+}
+
+// printRollbackSummary shows each setting before and after the rollback, as a diagnostic so
+// that it is not mixed into the output of scripts
+func printRollbackSummary(before, after ssm.ParameterHistory) {
+	tier := aws.StringValue(after.Tier)
+	if aws.StringValue(before.Tier) == ssm.ParameterTierAdvanced {
+		tier = ssm.ParameterTierAdvanced // Advanced parameters cannot be downgraded
+	}
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"Value", maskedValue(before.Type, before.Value), maskedValue(after.Type, after.Value)},
+		{"Type", aws.StringValue(before.Type), aws.StringValue(after.Type)},
+		{"Key", aws.StringValue(before.KeyId), aws.StringValue(after.KeyId)},
+		{"Description", aws.StringValue(before.Description), aws.StringValue(after.Description)},
+		{"Pattern", aws.StringValue(before.AllowedPattern), aws.StringValue(after.AllowedPattern)},
+		{"Tier", aws.StringValue(before.Tier), tier},
+		{"Policies", policyTexts(before.Policies), policyTexts(after.Policies)},
+	}
+	for _, f := range fields {
+		if f.before == f.after {
+			printDiagnostic("  %-12s %s\n", f.name+":", f.before)
+		} else {
+			printDiagnostic("  %-12s %s -> %s\n", f.name+":", f.before, f.after)
+		}
+	}
+}
+
+// policyTexts joins the text of the policies attached to a parameter
+func policyTexts(policies []*ssm.ParameterInlinePolicy) string {
+	var texts []string
+	for _, p := range policies {
+		texts = append(texts, aws.StringValue(p.PolicyText))
+	}
+	return strings.Join(texts, ",")
+}
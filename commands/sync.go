@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const syncUsage string = `
+sync usage: sync [-f] [--delete] [--dry-run] [--no-tags] [--no-tier] [--no-policies] src dst
+Make the parameters beneath dst mirror those beneath src. Only parameters whose value or
+metadata differ are written. Prints the planned actions before applying them. Either path
+may be prefixed with a region and/or a profile from the AWS config as profile@region:path.
+  -f Do not ask for confirmation, except for protected paths
+  --delete Delete parameters that exist only beneath dst
+  --dry-run Print the plan without applying it
+  --no-tags, --no-tier, --no-policies As for cp. Excluded fields are not compared.
+Tags are never compared: they are copied with created and updated parameters only.
+KMS keys are not compared or copied between regions or profiles.
+Example:
+/> sync --delete us-east-1:/prod/app us-west-2:/prod/app
+/> sync --dry-run /prod/app dr@us-west-2:/prod/app
+`
+
+func syncPaths(c *ishell.Context) error {
+	args, opts := checkCopyOptions(c.Args)
+	args, force := checkFlag(args, "-f")
+	args, del := checkFlag(args, "--delete")
+	paths, dryRun := checkFlag(args, "--dry-run")
+	if len(paths) != 2 {
+		return &usageError{"Expected src and dst", syncUsage}
+	}
+	src, err := parsePath(paths[0])
+	if err != nil {
+		return err
+	}
+	dst, err := parsePath(paths[1])
+	if err != nil {
+		return err
+	}
+	plan, err := ps.SyncPlan(src, dst, del, opts)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		printDiagnostic("Already in sync\n")
+		return nil
+	}
+	printSyncPlan(plan)
+	if dryRun {
+		return nil
+	}
+	var names []string
+	for _, action := range plan {
+		names = append(names, action.Dst.Name)
+	}
+	err = confirmChange("Apply "+countNoun(len(names))+"?", names, force)
+	if err != nil {
+		return err
+	}
+	err = ps.Sync(plan, opts)
+	resetCompletions()
+	return err
+}
+
+// printSyncPlan prints one line per sync action as a diagnostic
+func printSyncPlan(plan []parameterstore.SyncAction) {
+	for _, action := range plan {
+		switch action.Action {
+		case parameterstore.SyncUpdate:
+			printDiagnostic("%s %s (%s)\n", action.Action, action.Dst.Name, strings.Join(action.Changes, ", "))
+		default:
+			printDiagnostic("%s %s\n", action.Action, action.Dst.Name)
+		}
+	}
+}
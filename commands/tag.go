@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const tagUsage string = `
+tag usage: tag [-r] parameter ... tags=[key=value,...]
+Add tags to one or more parameters. Existing tags with the same key are replaced.
+Parameters may be matched with globs or a re: regular expression.
+  -r Tag all parameters beneath a path
+Example:
+/> tag /dev/app/url us-west-2:/dev/app/url tags=[Environment=dev,Team=payments]
+/> tag -r /dev tags=[Environment=dev]
+/> tag /dev/**/password tags=[Sensitive=true]
+`
+
+const tagsOption = "tags="
+
+func tag(c *ishell.Context) error {
+	args, recurse := checkRecursion(c.Args)
+	paths, tagList := splitTagsOption(args)
+	if len(paths) == 0 || tagList == "" {
+		return usage(tagUsage)
+	}
+	tags, err := parseTags(tagList)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	params, err := expandPaths(paths)
+	if err != nil {
+		return err
+	}
+	return ps.AddTags(params, tags, recurse)
+}
+
+// splitTagsOption separates a tags=[...] option from the rest of the arguments
+func splitTagsOption(args []string) (remaining []string, tagList string) {
+	for _, a := range args {
+		if strings.HasPrefix(strings.ToLower(a), tagsOption) {
+			tagList = a[len(tagsOption):]
+		} else {
+			remaining = append(remaining, a)
+		}
+	}
+	return remaining, tagList
+}
+
+// parseTagList parses a list of the form [a,b,c]
+func parseTagList(s string) ([]string, error) {
+	re := regexp.MustCompile(`^\[(.+)\]$`)
+	p := re.FindStringSubmatch(strings.TrimSpace(s))
+	if len(p) != 2 {
+		return nil, fmt.Errorf("unable to parse tag list %s", s)
+	}
+	return trim(strings.Split(p[1], ",")), nil
+}
+
+// parseTags parses tags of the form [key=value,...]
+func parseTags(s string) (tags []*ssm.Tag, err error) {
+	pairs, err := parseTagList(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("tag %q must be in the form key=value", pair)
+		}
+		tags = append(tags, &ssm.Tag{
+			Key:   aws.String(kv[0]),
+			Value: aws.String(kv[1]),
+		})
+	}
+	return tags, nil
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const tagsUsage string = `
+tags usage: tags [-r] parameter ...
+Display the tags of one or more parameters.
+  -r Display the tags of all parameters beneath a path
+`
+
+func tags(c *ishell.Context) error {
+	paths, recurse := checkRecursion(c.Args)
+	if len(paths) == 0 {
+		return usage(tagsUsage)
+	}
+	var params []parameterstore.ParameterPath
+	for _, p := range paths {
+		param, err := parsePath(p)
+		if err != nil {
+			return err
+		}
+		params = append(params, param)
+	}
+	resp, err := ps.ListTags(params, recurse)
+	if err != nil {
+		return err
+	}
+	return printResult(resp)
+}
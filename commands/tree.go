@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const treeUsage string = `
+tree usage: tree [-L depth] [-m] [path]
+Display the hierarchy beneath a path, which defaults to the current directory, followed
+by the number of paths and parameters shown.
+  -L Descend at most depth levels
+  -m Show the type, version and last modified date of each parameter
+Example:
+/> tree /prod
+/> tree -L 2 -m /prod/app
+`
+
+// treeTimeFormat is the format of last modified dates in tree output
+const treeTimeFormat = "2006-01-02 15:04:05"
+
+func tree(c *ishell.Context) error {
+	args, metadata := checkFlag(c.Args, "-m")
+	args, depthOption, err := checkOption(args, "-L")
+	if err != nil {
+		return &usageError{err.Error(), treeUsage}
+	}
+	depth := 0
+	if depthOption != "" {
+		depth, err = strconv.Atoi(depthOption)
+		if err != nil || depth < 1 {
+			return invalid("depth must be a positive number")
+		}
+	}
+	if len(args) > 1 {
+		return usage(treeUsage)
+	}
+	path := ps.Cwd
+	if len(args) == 1 {
+		path = args[0]
+	}
+	parameterPath, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	root, err := ps.Tree(parameterPath)
+	if err != nil {
+		return err
+	}
+	shell.Println(root.Name + treeMetadata(root, metadata))
+	paths, parameters := printTree(root, "", 1, depth, metadata)
+	shell.Printf("\n%d paths, %d parameters\n", paths, parameters)
+	return nil
+}
+
+// printTree prints the children of a node and returns the number of paths and parameters printed
+func printTree(node *parameterstore.TreeNode, indent string, level, depth int, metadata bool) (paths, parameters int) {
+	children := node.SortedChildren()
+	for i, c := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		shell.Println(indent + branch + c.Name + treeMetadata(c, metadata))
+		if c.Parameter != nil {
+			parameters++
+		}
+		if !c.IsPath() {
+			continue
+		}
+		paths++
+		if depth == 0 || level < depth {
+			p, n := printTree(c, indent+next, level+1, depth, metadata)
+			paths += p
+			parameters += n
+		}
+	}
+	return paths, parameters
+}
+
+// treeMetadata describes the parameter at a node
+func treeMetadata(node *parameterstore.TreeNode, metadata bool) string {
+	if !metadata || node.Parameter == nil {
+		return ""
+	}
+	p := node.Parameter
+	return fmt.Sprintf("  [%s v%d %s]", aws.StringValue(p.Type), aws.Int64Value(p.Version),
+		aws.TimeValue(p.LastModifiedDate).Local().Format(treeTimeFormat))
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const undoUsage string = `
+undo usage: undo [-f] [n]
+Revert the last n operations made in this session, most recent first. Defaults to 1.
+Parameters that were changed or deleted are restored to their previous version as a
+new version, and parameters that were created are deleted. Tags added by put and cp are
+removed or set back to their previous values. Changes made with tag and untag are not
+recorded. An operation cannot be undone if the state of a parameter before it could not be
+read, e.g. without permission to decrypt it. Use journal to list the operations.
+  -f Do not ask for confirmation, except for protected paths
+Example:
+/> undo
+/> undo 3
+`
+
+const journalUsage string = `
+journal usage: journal
+List the operations made in this session that can be undone, most recent first.
+`
+
+func undo(c *ishell.Context) error {
+	args, force := checkFlag(c.Args, "-f")
+	if len(args) > 1 {
+		return usage(undoUsage)
+	}
+	n := 1
+	if len(args) == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return invalid("expected a number of operations to undo")
+		}
+	}
+	ops := ps.Journal()
+	if n > len(ops) {
+		return invalid("cannot undo %d operations, the journal has %d", n, len(ops))
+	}
+	var names []string
+	for i := len(ops) - 1; i >= len(ops)-n; i-- {
+		printOperation(printDiagnostic, len(ops)-i, ops[i])
+		for _, change := range ops[i].Changes {
+			names = append(names, change.Name)
+		}
+	}
+	question := "Undo the last operation?"
+	if n > 1 {
+		question = "Undo the last " + strconv.Itoa(n) + " operations?"
+	}
+	err := confirmChange(question, names, force)
+	if err != nil {
+		return err
+	}
+	undone, err := ps.Undo(n)
+	resetCompletions()
+	for _, op := range undone {
+		printDiagnostic("Undid %s\n", op.Description)
+	}
+	return err
+}
+
+func journal(c *ishell.Context) error {
+	ops := ps.Journal()
+	if len(ops) == 0 {
+		printDiagnostic("No operations to undo\n")
+		return nil
+	}
+	for i := len(ops) - 1; i >= 0; i-- {
+		printOperation(shell.Printf, len(ops)-i, ops[i])
+	}
+	return nil
+}
+
+// printOperation prints an operation in the journal and the state each change would be reverted to,
+// as the result of journal or as a diagnostic before undo
+func printOperation(printf func(format string, args ...interface{}), n int, op parameterstore.Operation) {
+	printf("%d  %s  %s\n", n, op.Time.Format("15:04:05"), op.Description)
+	for _, change := range op.Changes {
+		previous := "did not exist"
+		if change.Unknown {
+			previous = "previous state unknown, cannot be undone"
+		} else if change.Existed {
+			previous = "was version " + strconv.FormatInt(aws.Int64Value(change.Previous.Version), 10)
+		}
+		printf("     %s %s (%s)\n", change.Action, change.Name, previous)
+	}
+}
+
+// operationDescription describes a command for the journal, hiding any value given to put
+func operationDescription(name string, args []string) string {
+	words := []string{name}
+	for _, a := range args {
+		if strings.HasPrefix(strings.ToLower(a), "value=") {
+			a = "value=" + secureStringMask
+		}
+		words = append(words, a)
+	}
+	return strings.Join(words, " ")
+}
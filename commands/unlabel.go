@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"github.com/abiosoft/ishell"
+)
+
+const unlabelUsage string = `
+unlabel usage: unlabel parameter[:version] label ...
+Remove labels from a parameter. Labels are removed from the selected version,
+or from whichever version they are attached to if no version is given.
+Example:
+/> unlabel /dev/app/url rollback
+/> unlabel /dev/app/url:3 prod-approved
+`
+
+func unlabel(c *ishell.Context) error {
+	if len(c.Args) < 2 {
+		return usage(unlabelUsage)
+	}
+	param, err := parsePath(c.Args[0])
+	if err != nil {
+		return err
+	}
+	return ps.UnlabelParameterVersion(param, c.Args[1:])
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"github.com/abiosoft/ishell"
+	"github.com/bwhaley/ssmsh/parameterstore"
+)
+
+const untagUsage string = `
+untag usage: untag [-r] parameter ... tags=[key,...]
+Remove tags from one or more parameters.
+  -r Untag all parameters beneath a path
+Example:
+/> untag /dev/app/url tags=[Team]
+/> untag -r us-west-2:/dev tags=[Environment,Team]
+`
+
+func untag(c *ishell.Context) error {
+	args, recurse := checkRecursion(c.Args)
+	paths, tagList := splitTagsOption(args)
+	if len(paths) == 0 || tagList == "" {
+		return usage(untagUsage)
+	}
+	keys, err := parseTagList(tagList)
+	if err != nil {
+		return invalid("%s", err)
+	}
+	var params []parameterstore.ParameterPath
+	for _, p := range paths {
+		param, err := parsePath(p)
+		if err != nil {
+			return err
+		}
+		params = append(params, param)
+	}
+	return ps.RemoveTags(params, keys, recurse)
+}
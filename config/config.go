@@ -12,13 +12,26 @@ const DefaultConfigFileName = ".ssmshrc"
 // Config holds the default shell configuration
 type Config struct {
 	Default struct {
-		Decrypt   bool
-		Key       string
-		Profile   string
-		Region    string
-		Overwrite bool
-		Type      string
-		Output    string
+		Decrypt     bool
+		Key         string
+		Profile     string
+		Region      string
+		Overwrite   bool
+		Type        string
+		Output      string
+		ReadOnly    bool
+		AuditLog    string
+		Endpoint    string
+		KMSEndpoint string
+		STSEndpoint string
+		FIPS        bool
+		DualStack   bool
+		CABundle    string `gcfg:"ca-bundle"`
+		MaxRetries  *int   `gcfg:"max-retries"`
+	}
+	Protect struct {
+		Confirm []string // Path prefixes that require typing the path to confirm changes
+		Refuse  []string // Path prefixes that cannot be changed
 	}
 }
 
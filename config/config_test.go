@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwhaley/ssmsh/config"
+)
+
+func TestReadConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), config.DefaultConfigFileName)
+	err := os.WriteFile(cfgFile, []byte(`[default]
+region=us-east-1
+endpoint=http://localhost:4583
+ca-bundle=/etc/ssl/certs/corporate-ca.pem
+max-retries=5
+
+[protect]
+confirm=/prod
+refuse=/prod/root
+`), 0600)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	cfg, err := config.ReadConfig(cfgFile)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if cfg.Default.Region != "us-east-1" || cfg.Default.Endpoint != "http://localhost:4583" {
+		t.Fatalf("unexpected defaults %+v", cfg.Default)
+	}
+	if cfg.Default.CABundle != "/etc/ssl/certs/corporate-ca.pem" {
+		t.Fatalf("expected the CA bundle to be read, got %q", cfg.Default.CABundle)
+	}
+	if cfg.Default.MaxRetries == nil || *cfg.Default.MaxRetries != 5 {
+		t.Fatalf("expected 5 retries, got %v", cfg.Default.MaxRetries)
+	}
+	if len(cfg.Protect.Confirm) != 1 || len(cfg.Protect.Refuse) != 1 {
+		t.Fatalf("unexpected protected paths %+v", cfg.Protect)
+	}
+}
+
+func TestReadConfigMissing(t *testing.T) {
+	cfg, err := config.ReadConfig(filepath.Join(t.TempDir(), config.DefaultConfigFileName))
+	if err != nil {
+		t.Fatal("expected no error for a missing config file, got", err)
+	}
+	if cfg.Default.MaxRetries != nil {
+		t.Fatal("expected an empty config")
+	}
+}
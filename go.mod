@@ -4,24 +4,24 @@ go 1.17
 
 require (
 	github.com/abiosoft/ishell v2.0.1-0.20181228190644-8b8aa74a8512+incompatible
+	github.com/abiosoft/readline v0.0.0-20180607040430-155bce2042db
 	github.com/aws/aws-sdk-go v1.50.16
+	github.com/fatih/color v1.10.0
 	github.com/mattn/go-shellwords v1.0.12
+	golang.org/x/crypto v0.14.0
 	gopkg.in/gcfg.v1 v1.2.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/abiosoft/readline v0.0.0-20180607040430-155bce2042db // indirect
 	github.com/chzyer/logex v1.1.10 // indirect
 	github.com/chzyer/test v0.0.0-20180213035817-a1ea475d72b1 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fatih/color v1.10.0 // indirect
 	github.com/flynn-archive/go-shlex v0.0.0-20150515145356-3f9db97f8568 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/mattn/go-colorable v0.1.8 // indirect
 	github.com/mattn/go-isatty v0.0.12 // indirect
 	github.com/stretchr/testify v1.6.1 // indirect
-	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sys v0.13.0 // indirect
-	golang.org/x/text v0.13.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
 )
@@ -0,0 +1,250 @@
+package parameterstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	saws "github.com/bwhaley/ssmsh/aws"
+)
+
+// AuditEntry is a line in the audit log, describing a call that modified a parameter.
+// Parameter values are never recorded.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Identity   string    `json:"identity"`
+	Profile    string    `json:"profile"`
+	Region     string    `json:"region"`
+	Action     string    `json:"action"`
+	Name       string    `json:"name"`
+	OldVersion int64     `json:"old_version,omitempty"`
+	NewVersion int64     `json:"new_version,omitempty"`
+	Labels     []string  `json:"labels,omitempty"`
+	TagKeys    []string  `json:"tag_keys,omitempty"`
+	Command    string    `json:"command,omitempty"`
+}
+
+// OpenAuditLog appends audit entries to a file, creating it and its directory if needed.
+// A leading ~ in the path is expanded to the home directory.
+func (ps *ParameterStore) OpenAuditLog(path string) error {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	ps.AuditLog = f
+	return nil
+}
+
+// auditor writes an audit entry for each successful call that modifies parameters and
+// passes every other call through to the client
+type auditor struct {
+	ssmiface.SSMAPI
+	ps  *ParameterStore
+	key string
+}
+
+func (a *auditor) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	entry, err := a.entry("PutParameter", aws.StringValue(in.Name))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.SSMAPI.PutParameter(in)
+	if err != nil {
+		return resp, err
+	}
+	entry.NewVersion = aws.Int64Value(resp.Version)
+	if entry.NewVersion > 1 {
+		entry.OldVersion = entry.NewVersion - 1
+	}
+	for _, t := range in.Tags {
+		entry.TagKeys = append(entry.TagKeys, aws.StringValue(t.Key))
+	}
+	return resp, a.write(entry)
+}
+
+func (a *auditor) DeleteParameters(in *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	entry, err := a.entry("DeleteParameters", "")
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]int64)
+	current, err := a.SSMAPI.GetParameters(&ssm.GetParametersInput{Names: in.Names})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range current.Parameters {
+		versions[aws.StringValue(p.Name)] = aws.Int64Value(p.Version)
+	}
+	resp, err := a.SSMAPI.DeleteParameters(in)
+	if err != nil {
+		return resp, err
+	}
+	for _, name := range resp.DeletedParameters {
+		entry.Name = aws.StringValue(name)
+		entry.OldVersion = versions[entry.Name]
+		err = a.write(entry)
+		if err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+func (a *auditor) LabelParameterVersion(in *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	entry, err := a.entry("LabelParameterVersion", aws.StringValue(in.Name))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.SSMAPI.LabelParameterVersion(in)
+	if err != nil {
+		return resp, err
+	}
+	entry.NewVersion = aws.Int64Value(resp.ParameterVersion)
+	entry.Labels = aws.StringValueSlice(in.Labels)
+	return resp, a.write(entry)
+}
+
+func (a *auditor) UnlabelParameterVersion(in *ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	entry, err := a.entry("UnlabelParameterVersion", aws.StringValue(in.Name))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.SSMAPI.UnlabelParameterVersion(in)
+	if err != nil {
+		return resp, err
+	}
+	entry.OldVersion = aws.Int64Value(in.ParameterVersion)
+	entry.Labels = aws.StringValueSlice(resp.RemovedLabels)
+	return resp, a.write(entry)
+}
+
+func (a *auditor) AddTagsToResource(in *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	entry, err := a.entry("AddTagsToResource", aws.StringValue(in.ResourceId))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.SSMAPI.AddTagsToResource(in)
+	if err != nil {
+		return resp, err
+	}
+	for _, t := range in.Tags {
+		entry.TagKeys = append(entry.TagKeys, aws.StringValue(t.Key))
+	}
+	return resp, a.write(entry)
+}
+
+func (a *auditor) RemoveTagsFromResource(in *ssm.RemoveTagsFromResourceInput) (*ssm.RemoveTagsFromResourceOutput, error) {
+	entry, err := a.entry("RemoveTagsFromResource", aws.StringValue(in.ResourceId))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.SSMAPI.RemoveTagsFromResource(in)
+	if err != nil {
+		return resp, err
+	}
+	entry.TagKeys = aws.StringValueSlice(in.TagKeys)
+	return resp, a.write(entry)
+}
+
+// entry starts an audit entry for a call. The caller identity is looked up before
+// the call is made so that changes are not made by callers that cannot be identified.
+func (a *auditor) entry(action, name string) (AuditEntry, error) {
+	profile, region := a.ps.splitClientKey(a.key)
+	identity, err := a.ps.identity(a.key)
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("could not identify the caller for the audit log: %v", err)
+	}
+	entry := AuditEntry{
+		Identity: identity,
+		Profile:  profile,
+		Region:   region,
+		Action:   action,
+		Name:     name,
+	}
+	if a.ps.operation != nil {
+		entry.Command = a.ps.operation.Description
+	}
+	return entry, nil
+}
+
+// write appends an entry to the audit log
+func (a *auditor) write(entry AuditEntry) error {
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = a.ps.AuditLog.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("%s %s succeeded but the audit log could not be written: %v", entry.Action, entry.Name, err)
+	}
+	return nil
+}
+
+// identity returns the ARN of the caller for a client key, looking it up with STS once
+func (ps *ParameterStore) identity(key string) (string, error) {
+	if arn, ok := ps.identities[key]; ok {
+		return arn, nil
+	}
+	client, err := ps.stsClient(key)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	if ps.identities == nil {
+		ps.identities = make(map[string]string)
+	}
+	ps.identities[key] = aws.StringValue(resp.Arn)
+	return ps.identities[key], nil
+}
+
+// stsClient returns an STS client for a client key, creating it if needed
+func (ps *ParameterStore) stsClient(key string) (stsiface.STSAPI, error) {
+	if ps.STSClients == nil {
+		ps.STSClients = make(map[string]stsiface.STSAPI)
+	}
+	if _, ok := ps.STSClients[key]; !ok {
+		profile, region := ps.splitClientKey(key)
+		if ps.NewSTSClient != nil {
+			ps.STSClients[key] = ps.NewSTSClient(region, profile)
+		} else {
+			client, err := saws.NewSTS(region, profile, ps.Session)
+			if err != nil {
+				return nil, err
+			}
+			ps.STSClients[key] = client
+		}
+	}
+	return ps.STSClients[key], nil
+}
+
+// splitClientKey returns the profile and region of a client key
+func (ps *ParameterStore) splitClientKey(key string) (profile, region string) {
+	parts := strings.SplitN(key, ProfileDelimiter, 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return ps.Profile, key
+}
@@ -0,0 +1,419 @@
+package parameterstore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	saws "github.com/bwhaley/ssmsh/aws"
+	"golang.org/x/crypto/scrypt"
+)
+
+// backupMagic identifies an ssmsh backup file
+const backupMagic = "SSMBAK1"
+
+// Key derivation for backups
+const (
+	KDFScrypt = "scrypt"
+	KDFKMS    = "kms"
+)
+
+// scrypt cost parameters for passphrase protected backups
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Limits on the scrypt cost parameters read from a backup, so that a crafted header
+// cannot make reading it use too much memory or time
+const (
+	maxScryptN      = 1 << 20
+	maxScryptRP     = 1 << 30 // r*p must be less than this
+	maxScryptMemory = 1 << 30 // scrypt uses 128*N*r bytes
+)
+
+// Backup holds every version, label, tag and policy of the parameters beneath a path
+type Backup struct {
+	Path       string
+	Region     string // The region of the backed up parameters, whose KMS keys belong to it
+	Profile    string // The profile used to back up the parameters, whose account the KMS keys belong to
+	Created    time.Time
+	Parameters []BackupParameter
+}
+
+// BackupParameter is the full history and tags of a single parameter
+type BackupParameter struct {
+	Name    string // Name relative to the backed up path
+	History []ssm.ParameterHistory
+	Tags    []ssm.Tag
+}
+
+// BackupKey describes how the key that encrypts a backup is obtained
+type BackupKey struct {
+	Passphrase []byte        // Derive the key from a passphrase with scrypt
+	KMSKeyID   string        // Or encrypt a data key with this KMS key
+	KMSPath    ParameterPath // The region and profile of the KMS key
+}
+
+// backupHeader is the unencrypted description of a backup file's encryption
+type backupHeader struct {
+	KDF          string
+	Salt         []byte `json:",omitempty"`
+	N            int    `json:",omitempty"`
+	R            int    `json:",omitempty"`
+	P            int    `json:",omitempty"`
+	KMSKeyID     string `json:",omitempty"`
+	Region       string `json:",omitempty"`
+	Profile      string `json:",omitempty"`
+	EncryptedKey []byte `json:",omitempty"`
+	Nonce        []byte
+}
+
+// Backup reads the full history and tags of the parameters beneath a path. Values are always decrypted.
+func (ps *ParameterStore) Backup(path ParameterPath) (*Backup, error) {
+	path.Name = fqp(path.Name, ps.Cwd)
+	params, err := ps.parametersByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) == 0 {
+		return nil, notFound("No parameters found beneath %s", path.Name)
+	}
+	profile := path.Profile
+	if profile == "" {
+		profile = ps.Profile
+	}
+	b := &Backup{Path: path.Name, Region: path.Region, Profile: profile, Created: time.Now().UTC()}
+	for _, p := range params {
+		history, err := ps.history(p, true)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := ps.listTags(p.Name, ps.ClientKey(p))
+		if err != nil {
+			return nil, err
+		}
+		b.Parameters = append(b.Parameters, BackupParameter{
+			Name:    relativeName(p.Name, path.Name),
+			History: history,
+			Tags:    tags,
+		})
+	}
+	sort.Slice(b.Parameters, func(i, j int) bool {
+		return b.Parameters[i].Name < b.Parameters[j].Name
+	})
+	return b, nil
+}
+
+// Restore recreates the parameters of a backup beneath a path, putting each version in order
+// and reattaching labels and tags. Policies are only applied to the latest version, since
+// those of earlier versions may have expired. Parameters that already exist are refused unless
+// overwrite is true, and then only the latest version is put over them, so that their old
+// history does not become new versions. KMS keys are left out when restoring to another region
+// or profile than the backup's, because keys belong to a region and account. Returns the number
+// of versions written.
+func (ps *ParameterStore) Restore(b *Backup, to ParameterPath, overwrite bool) (versions int, err error) {
+	to.Name = fqp(to.Name, ps.Cwd)
+	existing, err := ps.RestoreConflicts(b, to)
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) > 0 && !overwrite {
+		var names []string
+		for name := range existing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("cannot restore over existing parameters without overwrite: %s", strings.Join(names, ", "))
+	}
+	for _, p := range b.Parameters {
+		name := absoluteName(to.Name, p.Name)
+		history := p.History
+		sort.Slice(history, func(i, j int) bool {
+			return aws.Int64Value(history[i].Version) < aws.Int64Value(history[j].Version)
+		})
+		current, exists := existing[name]
+		if exists && len(history) > 0 {
+			history = history[len(history)-1:]
+		}
+		tier := aws.StringValue(current.Tier)
+		for i, h := range history {
+			input := putInputFromHistory(h, CopyOptions{SkipPolicies: i < len(history)-1})
+			input.Tier = tierFor(tier, input.Tier)
+			if input.Tier != nil {
+				tier = aws.StringValue(input.Tier)
+			}
+			if ps.ClientKey(b.Source()) != ps.ClientKey(to) {
+				input.KeyId = nil
+			}
+			input.Name = aws.String(name)
+			input.Overwrite = aws.Bool(exists || i > 0)
+			resp, err := ps.Put(input, ps.ClientKey(to))
+			if err != nil {
+				return versions, fmt.Errorf("%s version %d: %v", name, aws.Int64Value(h.Version), err)
+			}
+			versions++
+			if len(h.Labels) == 0 {
+				continue
+			}
+			_, err = ps.client(to).LabelParameterVersion(&ssm.LabelParameterVersionInput{
+				Name:             aws.String(name),
+				ParameterVersion: resp.Version,
+				Labels:           h.Labels,
+			})
+			if err != nil {
+				return versions, err
+			}
+		}
+		if len(p.Tags) > 0 {
+			var tags []*ssm.Tag
+			for i := range p.Tags {
+				tags = append(tags, &p.Tags[i])
+			}
+			err = ps.addTags(name, ps.ClientKey(to), tags)
+			if err != nil {
+				return versions, err
+			}
+		}
+	}
+	return versions, nil
+}
+
+// Source returns the path, region and profile that were backed up
+func (b *Backup) Source() ParameterPath {
+	return ParameterPath{Name: b.Path, Region: b.Region, Profile: b.Profile}
+}
+
+// RestoreConflicts returns the metadata of the parameters of a backup that already exist
+// beneath a path, keyed by name
+func (ps *ParameterStore) RestoreConflicts(b *Backup, to ParameterPath) (map[string]ssm.ParameterMetadata, error) {
+	to.Name = fqp(to.Name, ps.Cwd)
+	var params []ParameterPath
+	for _, p := range b.Parameters {
+		param := to
+		param.Name = absoluteName(to.Name, p.Name)
+		params = append(params, param)
+	}
+	return ps.Describe(params)
+}
+
+// WriteBackup compresses and encrypts a backup
+func (ps *ParameterStore) WriteBackup(w io.Writer, b *Backup, key BackupKey) error {
+	var plaintext bytes.Buffer
+	zw := gzip.NewWriter(&plaintext)
+	err := json.NewEncoder(zw).Encode(b)
+	if err != nil {
+		return err
+	}
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+
+	header := backupHeader{}
+	var dataKey []byte
+	if key.KMSKeyID != "" {
+		client, err := ps.KMSClient(key.KMSPath)
+		if err != nil {
+			return err
+		}
+		resp, err := client.GenerateDataKey(&kms.GenerateDataKeyInput{
+			KeyId:   aws.String(key.KMSKeyID),
+			KeySpec: aws.String(kms.DataKeySpecAes256),
+		})
+		if err != nil {
+			return err
+		}
+		dataKey = resp.Plaintext
+		header.KDF = KDFKMS
+		header.KMSKeyID = key.KMSKeyID
+		header.Region = key.KMSPath.Region
+		header.Profile = key.KMSPath.Profile
+		header.EncryptedKey = resp.CiphertextBlob
+	} else {
+		if len(key.Passphrase) == 0 {
+			return errors.New("a passphrase or KMS key is required to encrypt a backup")
+		}
+		header.KDF = KDFScrypt
+		header.N, header.R, header.P = scryptN, scryptR, scryptP
+		header.Salt = make([]byte, 16)
+		if _, err = rand.Read(header.Salt); err != nil {
+			return err
+		}
+		dataKey, err = scrypt.Key(key.Passphrase, header.Salt, header.N, header.R, header.P, 32)
+		if err != nil {
+			return err
+		}
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	header.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(header.Nonce); err != nil {
+		return err
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// The header is authenticated along with the archive
+	ciphertext := aead.Seal(nil, header.Nonce, plaintext.Bytes(), headerJSON)
+	_, err = fmt.Fprintf(w, "%s\n%s\n", backupMagic, headerJSON)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(ciphertext)
+	return err
+}
+
+// ReadBackup decrypts and decompresses a backup. passphrase is only called for passphrase protected backups.
+func (ps *ParameterStore) ReadBackup(r io.Reader, passphrase func() ([]byte, error)) (*Backup, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.ReadString('\n')
+	if err != nil || magic != backupMagic+"\n" {
+		return nil, errors.New("not an ssmsh backup file")
+	}
+	headerJSON, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("reading backup header: %v", err)
+	}
+	headerJSON = bytes.TrimSuffix(headerJSON, []byte("\n"))
+	var header backupHeader
+	err = json.Unmarshal(headerJSON, &header)
+	if err != nil {
+		return nil, fmt.Errorf("reading backup header: %v", err)
+	}
+	ciphertext, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataKey []byte
+	switch header.KDF {
+	case KDFKMS:
+		kmsPath := ParameterPath{Region: header.Region, Profile: header.Profile}
+		client, err := ps.KMSClient(kmsPath)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Decrypt(&kms.DecryptInput{
+			KeyId:          aws.String(header.KMSKeyID),
+			CiphertextBlob: header.EncryptedKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		dataKey = resp.Plaintext
+	case KDFScrypt:
+		if len(header.Salt) == 0 {
+			return nil, errors.New("invalid backup: the header has no salt")
+		}
+		err = checkScryptParams(header.N, header.R, header.P)
+		if err != nil {
+			return nil, err
+		}
+		p, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		dataKey, err = scrypt.Key(p, header.Salt, header.N, header.R, header.P, 32)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown backup key derivation %s", header.KDF)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(header.Nonce) != aead.NonceSize() {
+		// Open panics given a nonce of the wrong size
+		return nil, errors.New("invalid backup: the header has a nonce of the wrong size")
+	}
+	plaintext, err := aead.Open(nil, header.Nonce, ciphertext, headerJSON)
+	if err != nil {
+		return nil, errors.New("unable to decrypt backup, is the passphrase correct?")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	var b Backup
+	err = json.NewDecoder(zr).Decode(&b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// checkScryptParams returns an error if scrypt cost parameters are invalid or exceed the limits
+func checkScryptParams(n, r, p int) error {
+	switch {
+	case n < 2 || n&(n-1) != 0:
+		return fmt.Errorf("invalid backup header: scrypt N must be a power of 2 greater than 1, got %d", n)
+	case r < 1 || p < 1:
+		return fmt.Errorf("invalid backup header: scrypt r and p must be positive, got %d and %d", r, p)
+	case n > maxScryptN:
+		return fmt.Errorf("invalid backup header: scrypt N is %d, more than the maximum of %d", n, maxScryptN)
+	case int64(r)*int64(p) >= maxScryptRP:
+		return fmt.Errorf("invalid backup header: scrypt r*p must be less than %d", maxScryptRP)
+	case 128*int64(n)*int64(r) > maxScryptMemory:
+		return fmt.Errorf("invalid backup header: scrypt N and r would use more than %d bytes", maxScryptMemory)
+	}
+	return nil
+}
+
+// newAEAD returns an AES-GCM cipher for a 256 bit key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KMSClient returns a KMS client for a region and profile, creating it if needed
+func (ps *ParameterStore) KMSClient(path ParameterPath) (kmsiface.KMSAPI, error) {
+	if path.Region == "" {
+		path.Region = ps.Region
+	}
+	key := ps.ClientKey(path)
+	if ps.KMSClients == nil {
+		ps.KMSClients = make(map[string]kmsiface.KMSAPI)
+	}
+	if _, ok := ps.KMSClients[key]; !ok {
+		profile := path.Profile
+		if profile == "" {
+			profile = ps.Profile
+		}
+		if ps.NewKMSClient != nil {
+			ps.KMSClients[key] = ps.NewKMSClient(path.Region, profile)
+		} else {
+			client, err := saws.NewKMS(path.Region, profile, ps.Session)
+			if err != nil {
+				return nil, err
+			}
+			ps.KMSClients[key] = client
+		}
+	}
+	return ps.KMSClients[key], nil
+}
@@ -0,0 +1,57 @@
+package parameterstore
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// describeBatchSize is the maximum number of values in a DescribeParameters filter
+const describeBatchSize = 50
+
+// Describe returns the metadata of parameters keyed by name, using as few DescribeParameters
+// calls as possible. Names that are not parameters are omitted.
+func (ps *ParameterStore) Describe(params []ParameterPath) (map[string]ssm.ParameterMetadata, error) {
+	metadata := make(map[string]ssm.ParameterMetadata)
+	byClient := make(map[string][]ParameterPath)
+	for _, p := range params {
+		p.Name = fqp(p.Name, ps.Cwd)
+		key := ps.ClientKey(p)
+		byClient[key] = append(byClient[key], p)
+	}
+	for _, clientParams := range byClient {
+		for start := 0; start < len(clientParams); start += describeBatchSize {
+			end := start + describeBatchSize
+			if end > len(clientParams) {
+				end = len(clientParams)
+			}
+			batch := clientParams[start:end]
+			var names []string
+			for _, p := range batch {
+				names = append(names, p.Name)
+			}
+			input := &ssm.DescribeParametersInput{
+				ParameterFilters: []*ssm.ParameterStringFilter{
+					{
+						Key:    aws.String("Name"),
+						Option: aws.String("Equals"),
+						Values: aws.StringSlice(names),
+					},
+				},
+			}
+			for {
+				resp, err := ps.client(batch[0]).DescribeParameters(input)
+				if err != nil {
+					return nil, err
+				}
+				for _, m := range resp.Parameters {
+					metadata[aws.StringValue(m.Name)] = *m
+				}
+				if aws.StringValue(resp.NextToken) == "" {
+					break
+				}
+				input.NextToken = resp.NextToken
+			}
+		}
+	}
+	return metadata, nil
+}
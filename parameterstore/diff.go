@@ -0,0 +1,231 @@
+package parameterstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ParameterDiff describes how a parameter differs between two locations
+type ParameterDiff struct {
+	Name    string                // Name relative to the compared paths
+	Src     *ssm.ParameterHistory // Nil when the parameter exists only in the destination
+	Dst     *ssm.ParameterHistory // Nil when the parameter exists only in the source
+	Changes []FieldChange         // The fields that differ when the parameter exists in both
+}
+
+// FieldChange is a parameter field whose value differs between two locations
+type FieldChange struct {
+	Field string
+	Src   string
+	Dst   string
+}
+
+// Diff compares two parameters, two versions of a parameter, or the parameters beneath two paths.
+// Only the differences are returned. Values are always decrypted for comparison.
+func (ps *ParameterStore) Diff(src, dst ParameterPath, recurse bool) ([]ParameterDiff, error) {
+	src.Name = fqp(src.Name, ps.Cwd)
+	dst.Name = fqp(dst.Name, ps.Cwd)
+
+	srcIsParameter := ps.isParameter(src)
+	dstIsParameter := ps.isParameter(dst)
+	if srcIsParameter && dstIsParameter {
+		srcParam, err := ps.describeParameter(src)
+		if err != nil {
+			return nil, err
+		}
+		dstParam, err := ps.describeParameter(dst)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := splitSelector(src.Name)
+		return diffParameters(
+			map[string]ssm.ParameterHistory{name: srcParam},
+			map[string]ssm.ParameterHistory{name: dstParam},
+		), nil
+	}
+
+	srcIsPath := ps.isPath(src)
+	dstIsPath := ps.isPath(dst)
+	if srcIsParameter || dstIsParameter {
+		return nil, fmt.Errorf("Cannot compare a parameter with a path (%s, %s)", src.Name, dst.Name)
+	}
+	if !srcIsPath && !dstIsPath {
+		return nil, notFound("No path or parameter %s or %s was found", src.Name, dst.Name)
+	}
+	srcParams, err := ps.describePath(src, recurse)
+	if err != nil {
+		return nil, err
+	}
+	dstParams, err := ps.describePath(dst, recurse)
+	if err != nil {
+		return nil, err
+	}
+	return diffParameters(srcParams, dstParams), nil
+}
+
+// describeParameter returns the selected or latest version of a parameter with its value decrypted
+func (ps *ParameterStore) describeParameter(param ParameterPath) (ssm.ParameterHistory, error) {
+	history, err := ps.history(param, true)
+	if err != nil {
+		return ssm.ParameterHistory{}, err
+	}
+	if len(history) == 0 {
+		return ssm.ParameterHistory{}, notFound("no history found for %s", param.Name)
+	}
+	return history[len(history)-1], nil
+}
+
+// describePath returns the value and metadata of the parameters beneath a path,
+// keyed by their names relative to the path. Values are always decrypted.
+func (ps *ParameterStore) describePath(path ParameterPath, recurse bool) (map[string]ssm.ParameterHistory, error) {
+	params := make(map[string]ssm.ParameterHistory)
+	valuesInput := &ssm.GetParametersByPathInput{
+		Path:           aws.String(path.Name),
+		Recursive:      aws.Bool(recurse),
+		WithDecryption: aws.Bool(true),
+	}
+	for {
+		resp, err := ps.client(path).GetParametersByPath(valuesInput)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Parameters {
+			params[aws.StringValue(p.Name)] = ssm.ParameterHistory{
+				Name:             p.Name,
+				Value:            p.Value,
+				Type:             p.Type,
+				DataType:         p.DataType,
+				Version:          p.Version,
+				LastModifiedDate: p.LastModifiedDate,
+			}
+		}
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+		valuesInput.NextToken = resp.NextToken
+	}
+
+	metadata, err := ps.describeParameters(path, recurse, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metadata {
+		p, ok := params[aws.StringValue(m.Name)]
+		if !ok {
+			continue
+		}
+		p.KeyId = m.KeyId
+		p.Tier = m.Tier
+		p.Description = m.Description
+		p.AllowedPattern = m.AllowedPattern
+		p.Policies = m.Policies
+		p.LastModifiedUser = m.LastModifiedUser
+		params[aws.StringValue(m.Name)] = p
+	}
+
+	relative := make(map[string]ssm.ParameterHistory)
+	for name, p := range params {
+		relative[relativeName(name, path.Name)] = p
+	}
+	return relative, nil
+}
+
+// describeParameters returns the metadata of the parameters beneath a path that match the filters
+func (ps *ParameterStore) describeParameters(path ParameterPath, recurse bool, filters []*ssm.ParameterStringFilter) (r []*ssm.ParameterMetadata, err error) {
+	option := "OneLevel"
+	if recurse {
+		option = "Recursive"
+	}
+	input := &ssm.DescribeParametersInput{
+		ParameterFilters: append([]*ssm.ParameterStringFilter{
+			{
+				Key:    aws.String("Path"),
+				Option: aws.String(option),
+				Values: aws.StringSlice([]string{path.Name}),
+			},
+		}, filters...),
+	}
+	for {
+		resp, err := ps.client(path).DescribeParameters(input)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, resp.Parameters...)
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	return r, nil
+}
+
+// relativeName removes a path prefix from a parameter name
+func relativeName(name, path string) string {
+	if path == Delimiter {
+		return strings.TrimPrefix(name, Delimiter)
+	}
+	return strings.TrimPrefix(name, path+Delimiter)
+}
+
+// diffParameters compares two sets of parameters keyed by name
+func diffParameters(src, dst map[string]ssm.ParameterHistory) (diffs []ParameterDiff) {
+	var names []string
+	for name := range src {
+		names = append(names, name)
+	}
+	for name := range dst {
+		if _, ok := src[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s, inSrc := src[name]
+		d, inDst := dst[name]
+		switch {
+		case !inDst:
+			diffs = append(diffs, ParameterDiff{Name: name, Src: &s})
+		case !inSrc:
+			diffs = append(diffs, ParameterDiff{Name: name, Dst: &d})
+		default:
+			changes := compareParameters(s, d)
+			if len(changes) > 0 {
+				diffs = append(diffs, ParameterDiff{Name: name, Src: &s, Dst: &d, Changes: changes})
+			}
+		}
+	}
+	return diffs
+}
+
+// compareParameters returns the fields that differ between two parameters
+func compareParameters(src, dst ssm.ParameterHistory) (changes []FieldChange) {
+	fields := []FieldChange{
+		{"Value", aws.StringValue(src.Value), aws.StringValue(dst.Value)},
+		{"Type", aws.StringValue(src.Type), aws.StringValue(dst.Type)},
+		{"DataType", aws.StringValue(src.DataType), aws.StringValue(dst.DataType)},
+		{"Tier", aws.StringValue(src.Tier), aws.StringValue(dst.Tier)},
+		{"KeyId", aws.StringValue(src.KeyId), aws.StringValue(dst.KeyId)},
+		{"Description", aws.StringValue(src.Description), aws.StringValue(dst.Description)},
+		{"AllowedPattern", aws.StringValue(src.AllowedPattern), aws.StringValue(dst.AllowedPattern)},
+		{"Policies", policyText(src.Policies), policyText(dst.Policies)},
+	}
+	for _, f := range fields {
+		if f.Src != f.Dst {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// policyText returns the JSON representation of a parameter's policies, or an empty string if there are none
+func policyText(policies []*ssm.ParameterInlinePolicy) string {
+	if len(policies) == 0 {
+		return ""
+	}
+	return policiesJSON(policies)
+}
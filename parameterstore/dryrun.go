@@ -0,0 +1,82 @@
+package parameterstore
+
+import (
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// PlannedCall is a call that would modify parameters, recorded instead of made in dry run mode
+type PlannedCall struct {
+	Operation string      // The SSM API operation, e.g. PutParameter
+	Client    string      // The key of the client that would make the call, i.e. region or profile@region
+	Input     interface{} // The input to the operation, e.g. *ssm.PutParameterInput
+}
+
+// TakePlan returns the calls recorded in dry run mode since it was last called
+func (ps *ParameterStore) TakePlan() []PlannedCall {
+	plan := ps.plan
+	ps.plan = nil
+	return plan
+}
+
+// clientFor returns the SSM client with the given key. Calls that would modify parameters
+// are refused in read-only mode, recorded in the plan rather than made in dry run mode,
+// and written to the audit log when one is set. Calls that would modify parameters beneath
+// protected paths are always refused.
+func (ps *ParameterStore) clientFor(key string) ssmiface.SSMAPI {
+	client := ps.Clients[key]
+	switch {
+	case ps.ReadOnly:
+		client = readOnlyClient{client}
+	case ps.DryRun:
+		client = &planner{SSMAPI: client, ps: ps, key: key}
+	case ps.AuditLog != nil:
+		client = &auditor{SSMAPI: client, ps: ps, key: key}
+	}
+	if len(ps.Protected) > 0 {
+		// Changes beneath protected paths are refused before they are planned or audited
+		client = protector{SSMAPI: client, ps: ps}
+	}
+	return client
+}
+
+// planner records modifying calls and passes every other call through to the client
+type planner struct {
+	ssmiface.SSMAPI
+	ps  *ParameterStore
+	key string
+}
+
+func (p *planner) record(operation string, input interface{}) {
+	p.ps.plan = append(p.ps.plan, PlannedCall{Operation: operation, Client: p.key, Input: input})
+}
+
+func (p *planner) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	p.record("PutParameter", in)
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (p *planner) DeleteParameters(in *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	p.record("DeleteParameters", in)
+	return &ssm.DeleteParametersOutput{DeletedParameters: in.Names}, nil
+}
+
+func (p *planner) AddTagsToResource(in *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	p.record("AddTagsToResource", in)
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+func (p *planner) RemoveTagsFromResource(in *ssm.RemoveTagsFromResourceInput) (*ssm.RemoveTagsFromResourceOutput, error) {
+	p.record("RemoveTagsFromResource", in)
+	return &ssm.RemoveTagsFromResourceOutput{}, nil
+}
+
+func (p *planner) LabelParameterVersion(in *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	p.record("LabelParameterVersion", in)
+	return &ssm.LabelParameterVersionOutput{ParameterVersion: in.ParameterVersion}, nil
+}
+
+func (p *planner) UnlabelParameterVersion(in *ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	p.record("UnlabelParameterVersion", in)
+	return &ssm.UnlabelParameterVersionOutput{RemovedLabels: in.Labels}, nil
+}
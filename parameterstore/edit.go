@@ -0,0 +1,31 @@
+package parameterstore
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// EditTarget returns the latest version of a parameter with its value decrypted
+func (ps *ParameterStore) EditTarget(param ParameterPath) (ssm.ParameterHistory, error) {
+	name, selector := splitSelector(fqp(param.Name, ps.Cwd))
+	if selector != "" {
+		return ssm.ParameterHistory{}, fmt.Errorf("cannot edit version %s of %s, only the latest version", selector, name)
+	}
+	param.Name = name
+	return ps.describeParameter(param)
+}
+
+// Edit puts a new value for a parameter as a new version, preserving the type, key,
+// description, pattern, tier and policies of the current version
+func (ps *ParameterStore) Edit(param ParameterPath, current ssm.ParameterHistory, value string) (*ssm.PutParameterOutput, error) {
+	if value == aws.StringValue(current.Value) {
+		return nil, fmt.Errorf("%s is unchanged", aws.StringValue(current.Name))
+	}
+	putParamInput := putInputFromHistory(current, CopyOptions{})
+	putParamInput.Name = aws.String(fqp(param.Name, ps.Cwd))
+	putParamInput.Value = aws.String(value)
+	putParamInput.Overwrite = aws.Bool(true)
+	return ps.Put(putParamInput, ps.ClientKey(param))
+}
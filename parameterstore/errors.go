@@ -0,0 +1,26 @@
+package parameterstore
+
+import "fmt"
+
+// NotFoundError is returned when a parameter, version, label or path does not exist
+type NotFoundError struct {
+	msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.msg
+}
+
+// ProtectedError is returned for changes to parameters beneath a protected path
+type ProtectedError struct {
+	Prefix string // The protected path
+}
+
+func (e *ProtectedError) Error() string {
+	return fmt.Sprintf("%s is protected and cannot be changed", e.Prefix)
+}
+
+// notFound creates a NotFoundError with a formatted message
+func notFound(format string, args ...interface{}) error {
+	return &NotFoundError{msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,230 @@
+package parameterstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"gopkg.in/yaml.v3"
+)
+
+// Export formats
+const (
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+	FormatDotenv = "dotenv"
+	FormatShell  = "sh"
+	FormatTfvars = "tfvars"
+)
+
+// ExportFormats lists the formats supported by MarshalParameters
+var ExportFormats = []string{FormatJSON, FormatYAML, FormatDotenv, FormatShell, FormatTfvars}
+
+// ExportedParameter is a parameter with the metadata needed to recreate it
+type ExportedParameter struct {
+	Name        string            `json:"-" yaml:"-"` // Name relative to the exported path
+	Value       string            `json:"value" yaml:"value"`
+	Type        string            `json:"type" yaml:"type"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Key         string            `json:"key,omitempty" yaml:"key,omitempty"`
+	Tier        string            `json:"tier,omitempty" yaml:"tier,omitempty"`
+	Policies    string            `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+var nonIdentifierRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Export returns the parameters beneath a path along with their metadata and tags, sorted by name.
+// Values are always decrypted.
+func (ps *ParameterStore) Export(path ParameterPath, recurse bool) (r []ExportedParameter, err error) {
+	path.Name = fqp(path.Name, ps.Cwd)
+	params, err := ps.describePath(path, recurse)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) == 0 {
+		return nil, notFound("No parameters found beneath %s", path.Name)
+	}
+	for name, p := range params {
+		tags, err := ps.listTags(aws.StringValue(p.Name), ps.ClientKey(path))
+		if err != nil {
+			return nil, err
+		}
+		e := ExportedParameter{
+			Name:        name,
+			Value:       aws.StringValue(p.Value),
+			Type:        aws.StringValue(p.Type),
+			Description: aws.StringValue(p.Description),
+			Key:         aws.StringValue(p.KeyId),
+			Tier:        aws.StringValue(p.Tier),
+			Policies:    policyText(p.Policies),
+		}
+		for _, t := range tags {
+			if e.Tags == nil {
+				e.Tags = make(map[string]string)
+			}
+			e.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+		r = append(r, e)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Name < r[j].Name
+	})
+	return r, nil
+}
+
+// MarshalParameters encodes exported parameters in one of the ExportFormats.
+// JSON and YAML nest the hierarchy and include metadata, the other formats contain only values.
+func MarshalParameters(params []ExportedParameter, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		tree, err := nestParameters(params)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.MarshalIndent(tree, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case FormatYAML:
+		tree, err := nestParameters(params)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(tree)
+	case FormatDotenv, FormatShell, FormatTfvars:
+		var buf bytes.Buffer
+		exported := make(map[string]string)
+		for _, p := range params {
+			env := EnvName(p.Name)
+			if other, ok := exported[env]; ok {
+				return nil, fmt.Errorf("%s and %s would both be exported as %s", other, p.Name, env)
+			}
+			exported[env] = p.Name
+			switch format {
+			case FormatDotenv:
+				fmt.Fprintf(&buf, "%s=%s\n", EnvName(p.Name), doubleQuote(p.Value))
+			case FormatShell:
+				fmt.Fprintf(&buf, "export %s=%s\n", EnvName(p.Name), singleQuote(p.Value))
+			case FormatTfvars:
+				fmt.Fprintf(&buf, "%s = %s\n", strings.ToLower(EnvName(p.Name)), hclQuote(p.Value))
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown format %s, expected one of %s", format, strings.Join(ExportFormats, ", "))
+}
+
+// EnvName converts a relative parameter name to an UPPER_SNAKE variable name, e.g. db/read-url becomes DB_READ_URL
+func EnvName(name string) string {
+	n := strings.Trim(nonIdentifierRegexp.ReplaceAllString(name, "_"), "_")
+	if n == "" || (n[0] >= '0' && n[0] <= '9') {
+		n = "_" + n
+	}
+	return strings.ToUpper(n)
+}
+
+// nestParameters maps the hierarchy of parameter names to nested objects. A name that is
+// both a parameter and a path holds its metadata alongside its children, so its children
+// cannot be named like metadata fields.
+func nestParameters(params []ExportedParameter) (map[string]interface{}, error) {
+	err := checkFieldNames(params)
+	if err != nil {
+		return nil, err
+	}
+	tree := make(map[string]interface{})
+	for _, p := range params {
+		elements := strings.Split(strings.Trim(p.Name, Delimiter), Delimiter)
+		node := tree
+		for _, e := range elements[:len(elements)-1] {
+			child, err := branch(node, e)
+			if err != nil {
+				return nil, err
+			}
+			node = child
+		}
+		leaf := elements[len(elements)-1]
+		if children, ok := node[leaf].(map[string]interface{}); ok {
+			fields, err := parameterFields(p)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range fields {
+				children[k] = v
+			}
+			continue
+		}
+		node[leaf] = p
+	}
+	return tree, nil
+}
+
+// checkFieldNames returns an error if a parameter is beneath another parameter and would be
+// nested under a key that holds the other parameter's metadata
+func checkFieldNames(params []ExportedParameter) error {
+	names := make(map[string]bool)
+	for _, p := range params {
+		names[strings.Trim(p.Name, Delimiter)] = true
+	}
+	for _, p := range params {
+		elements := strings.Split(strings.Trim(p.Name, Delimiter), Delimiter)
+		for i := 1; i < len(elements); i++ {
+			parent := strings.Join(elements[:i], Delimiter)
+			if names[parent] && parameterFieldNames[elements[i]] {
+				return fmt.Errorf("%s cannot be exported because %s is also a parameter with a %s field", p.Name, parent, elements[i])
+			}
+		}
+	}
+	return nil
+}
+
+// branch returns the child object of a node, converting a parameter to an object if needed
+func branch(node map[string]interface{}, name string) (map[string]interface{}, error) {
+	switch child := node[name].(type) {
+	case map[string]interface{}:
+		return child, nil
+	case ExportedParameter:
+		fields, err := parameterFields(child)
+		if err != nil {
+			return nil, err
+		}
+		node[name] = fields
+		return fields, nil
+	}
+	child := make(map[string]interface{})
+	node[name] = child
+	return child, nil
+}
+
+// parameterFields returns the encoded fields of a parameter as a map
+func parameterFields(p ExportedParameter) (map[string]interface{}, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	err = json.Unmarshal(b, &fields)
+	return fields, err
+}
+
+// doubleQuote quotes a value for a dotenv file
+func doubleQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// singleQuote quotes a value for a POSIX shell
+func singleQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// hclQuote quotes a value for a Terraform variables file, escaping template sequences
+func hclQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + r.Replace(s) + `"`
+}
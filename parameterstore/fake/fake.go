@@ -0,0 +1,295 @@
+// Package fake provides an in-memory implementation of the SSM API for offline use and testing.
+// It models the parameter hierarchy, versions, labels, tags, tiers and policies, pages results
+// like the service does, and returns the same errors for missing, existing and invalid parameters.
+package fake
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+const (
+	// DefaultKeyID is the key used for SecureString parameters when none is given
+	DefaultKeyID = "alias/aws/ssm"
+	// DefaultAccount is the account ID used in ARNs
+	DefaultAccount = "123456789012"
+	// DefaultUser is recorded as the user that last modified each parameter
+	DefaultUser = "arn:aws:iam::123456789012:user/ssmsh"
+
+	// ErrCodeValidationException is returned for invalid requests. The SDK does not define it.
+	ErrCodeValidationException = "ValidationException"
+
+	delimiter           = "/"
+	selectorDelimiter   = ":"
+	maxPathResults      = 10
+	maxDescribeResults  = 50
+	maxHistoryResults   = 50
+	maxNames            = 10
+	maxFilterValues     = 50
+	maxVersions         = 100
+	maxLabelsPerVersion = 10
+	maxTags             = 50
+	maxDepth            = 15
+	maxNameLength       = 1011
+	standardValueSize   = 4096
+	advancedValueSize   = 8192
+)
+
+// SSM is an in-memory SSM parameter store. Operations that ssmsh does not use panic
+// through the embedded nil interface.
+type SSM struct {
+	ssmiface.SSMAPI
+	Region  string // Used in ARNs
+	Account string // Used in ARNs
+	User    string // Recorded as the last modifier of each parameter
+
+	mu         sync.Mutex
+	parameters map[string]*Parameter
+}
+
+// Parameter is the state of a parameter: every version, oldest first, and the tags
+type Parameter struct {
+	History []*ssm.ParameterHistory
+	Tags    map[string]string
+}
+
+// New creates an empty parameter store for a region
+func New(region string) *SSM {
+	return &SSM{
+		Region:     region,
+		Account:    DefaultAccount,
+		User:       DefaultUser,
+		parameters: make(map[string]*Parameter),
+	}
+}
+
+// newError creates an error like those returned by the service
+func newError(code, format string, args ...interface{}) error {
+	return awserr.New(code, fmt.Sprintf(format, args...), nil)
+}
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.\-/]+$`)
+
+// validateName checks a parameter name against the rules of the service
+func validateName(name string) error {
+	switch {
+	case name == "" || len(name) > maxNameLength || !nameRegexp.MatchString(name):
+		return newError(ErrCodeValidationException, "Parameter name %q must be 1 to %d letters, numbers, and the symbols .-_/", name, maxNameLength)
+	case strings.Contains(name, delimiter) && !strings.HasPrefix(name, delimiter):
+		return newError(ErrCodeValidationException, "Parameter name %q must be fully qualified", name)
+	case strings.HasSuffix(name, delimiter) || strings.Contains(name, delimiter+delimiter):
+		return newError(ErrCodeValidationException, "Parameter name %q has an empty level of the hierarchy", name)
+	case strings.Count(name, delimiter) > maxDepth:
+		return newError(ssm.ErrCodeHierarchyLevelLimitExceededException, "Parameter name %q has more than %d levels", name, maxDepth)
+	}
+	first := strings.ToLower(strings.SplitN(strings.TrimPrefix(name, delimiter), delimiter, 2)[0])
+	if strings.HasPrefix(first, "aws") || strings.HasPrefix(first, "ssm") {
+		return newError(ErrCodeValidationException, "Parameter name %q can't be prefixed with \"aws\" or \"ssm\"", name)
+	}
+	return nil
+}
+
+// splitSelector separates a name from a version or label selector, e.g. /app/url:3
+func splitSelector(name string) (string, string) {
+	i := strings.LastIndex(name, selectorDelimiter)
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
+
+// latest returns the latest version of a parameter
+func (p *Parameter) latest() *ssm.ParameterHistory {
+	return p.History[len(p.History)-1]
+}
+
+// version returns the version of a parameter with a number or label
+func (p *Parameter) version(selector string) *ssm.ParameterHistory {
+	if v, err := strconv.ParseInt(selector, 10, 64); err == nil {
+		for _, h := range p.History {
+			if aws.Int64Value(h.Version) == v {
+				return h
+			}
+		}
+		return nil
+	}
+	for _, h := range p.History {
+		for _, l := range h.Labels {
+			if aws.StringValue(l) == selector {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// lookup finds the version of a parameter selected by a name such as /app/url, /app/url:3 or /app/url:label
+func (s *SSM) lookup(name string) (*ssm.ParameterHistory, error) {
+	base, selector := splitSelector(name)
+	p, ok := s.parameters[base]
+	if !ok {
+		return nil, newError(ssm.ErrCodeParameterNotFound, "Parameter %s not found.", base)
+	}
+	if selector == "" {
+		return p.latest(), nil
+	}
+	h := p.version(selector)
+	if h == nil {
+		return nil, newError(ssm.ErrCodeParameterVersionNotFound, "Systems Manager could not find version %s of %s.", selector, base)
+	}
+	return h, nil
+}
+
+// arn returns the ARN of a parameter
+func (s *SSM) arn(name string) string {
+	return fmt.Sprintf("arn:aws:ssm:%s:%s:parameter%s%s", s.Region, s.Account, delimiter, strings.TrimPrefix(name, delimiter))
+}
+
+// value returns the value of a version, which stands in for ciphertext when a SecureString is not decrypted
+func value(h *ssm.ParameterHistory, decrypt bool) *string {
+	if aws.StringValue(h.Type) == ssm.ParameterTypeSecureString && !decrypt {
+		return aws.String(ciphertext(aws.StringValue(h.KeyId), aws.StringValue(h.Value)))
+	}
+	return h.Value
+}
+
+// ciphertext produces an opaque stand-in for an encrypted value
+func ciphertext(keyID, value string) string {
+	return fmt.Sprintf("AQICAH%x", []byte(keyID+selectorDelimiter+value))
+}
+
+// parameter converts a version to the result of GetParameter and similar calls
+func (s *SSM) parameter(h *ssm.ParameterHistory, selector string, decrypt bool) *ssm.Parameter {
+	p := &ssm.Parameter{
+		ARN:              aws.String(s.arn(aws.StringValue(h.Name))),
+		DataType:         h.DataType,
+		LastModifiedDate: h.LastModifiedDate,
+		Name:             h.Name,
+		Type:             h.Type,
+		Value:            value(h, decrypt),
+		Version:          h.Version,
+	}
+	if selector != "" {
+		p.Selector = aws.String(selectorDelimiter + selector)
+	}
+	return p
+}
+
+// metadata converts the latest version of a parameter to the result of DescribeParameters
+func metadata(h *ssm.ParameterHistory) *ssm.ParameterMetadata {
+	return &ssm.ParameterMetadata{
+		AllowedPattern:   h.AllowedPattern,
+		DataType:         h.DataType,
+		Description:      h.Description,
+		KeyId:            h.KeyId,
+		LastModifiedDate: h.LastModifiedDate,
+		LastModifiedUser: h.LastModifiedUser,
+		Name:             h.Name,
+		Policies:         h.Policies,
+		Tier:             h.Tier,
+		Type:             h.Type,
+		Version:          h.Version,
+	}
+}
+
+// copyHistory copies a version so that callers cannot modify the store
+func copyHistory(h *ssm.ParameterHistory, decrypt bool) *ssm.ParameterHistory {
+	c := *h
+	c.Labels = append([]*string(nil), h.Labels...)
+	c.Value = value(h, decrypt)
+	return &c
+}
+
+// names returns the names of all parameters, sorted
+func (s *SSM) names() []string {
+	var names []string
+	for name := range s.parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// page returns the start and end of a page of n results and the token for the next page
+func page(n int, token *string, maxResults *int64, limit int) (start, end int, next *string, err error) {
+	size := limit
+	if maxResults != nil {
+		size = int(aws.Int64Value(maxResults))
+		if size < 1 || size > limit {
+			return 0, 0, nil, newError(ErrCodeValidationException, "MaxResults must be between 1 and %d", limit)
+		}
+	}
+	if aws.StringValue(token) != "" {
+		start, err = strconv.Atoi(aws.StringValue(token))
+		if err != nil || start < 0 || start > n {
+			return 0, 0, nil, newError(ssm.ErrCodeInvalidNextToken, "The specified token is not valid.")
+		}
+	}
+	end = start + size
+	if end < n {
+		next = aws.String(strconv.Itoa(end))
+	} else {
+		end = n
+	}
+	return start, end, next, nil
+}
+
+// now returns the current time, rounded like the timestamps returned by the service
+func now() *time.Time {
+	t := time.Now().UTC().Truncate(time.Millisecond)
+	return &t
+}
+
+// Regions holds an in-memory parameter store and key store for each region, shared by every profile
+type Regions struct {
+	mu     sync.Mutex
+	stores map[string]*SSM
+	keys   map[string]*KMS
+}
+
+// NewRegions creates an empty set of regional parameter stores
+func NewRegions() *Regions {
+	return &Regions{stores: make(map[string]*SSM), keys: make(map[string]*KMS)}
+}
+
+// Client returns the parameter store for a region, creating it if needed. The profile is ignored.
+func (r *Regions) Client(region, profile string) ssmiface.SSMAPI {
+	return r.Store(region)
+}
+
+// Store returns the parameter store for a region, creating it if needed
+func (r *Regions) Store(region string) *SSM {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.stores[region]; !ok {
+		r.stores[region] = New(region)
+	}
+	return r.stores[region]
+}
+
+// KMSClient returns the key store for a region, creating it if needed. The profile is ignored.
+func (r *Regions) KMSClient(region, profile string) kmsiface.KMSAPI {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[region]; !ok {
+		r.keys[region] = NewKMS(region)
+	}
+	return r.keys[region]
+}
+
+// STSClient returns an identity service for a region. The profile is ignored.
+func (r *Regions) STSClient(region, profile string) stsiface.STSAPI {
+	return NewSTS()
+}
@@ -0,0 +1,472 @@
+package fake_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/bwhaley/ssmsh/parameterstore/fake"
+)
+
+// errorCode returns the code of an error returned by the fake
+func errorCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return ""
+}
+
+func put(t *testing.T, s *fake.SSM, name, value string, overwrite bool) int64 {
+	resp, err := s.PutParameter(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      aws.String("String"),
+		Overwrite: aws.Bool(overwrite),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	return aws.Int64Value(resp.Version)
+}
+
+func TestPutAndGet(t *testing.T) {
+	s := fake.New("region")
+	if v := put(t, s, "/House/Stark/AryaStark", "No one", false); v != 1 {
+		t.Fatalf("expected version 1, got %d", v)
+	}
+	_, err := s.PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/House/Stark/AryaStark"),
+		Value: aws.String("Arya"),
+		Type:  aws.String("String"),
+	})
+	if errorCode(err) != ssm.ErrCodeParameterAlreadyExists {
+		t.Fatalf("expected ParameterAlreadyExists, got %v", err)
+	}
+	if v := put(t, s, "/House/Stark/AryaStark", "Arya of House Stark", true); v != 2 {
+		t.Fatalf("expected version 2, got %d", v)
+	}
+
+	resp, err := s.GetParameter(&ssm.GetParameterInput{Name: aws.String("/House/Stark/AryaStark:1")})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.StringValue(resp.Parameter.Value) != "No one" || aws.StringValue(resp.Parameter.Selector) != ":1" {
+		t.Fatalf("expected version 1, got %+v", resp.Parameter)
+	}
+	_, err = s.GetParameter(&ssm.GetParameterInput{Name: aws.String("/House/Stark/SansaStark")})
+	if errorCode(err) != ssm.ErrCodeParameterNotFound {
+		t.Fatalf("expected ParameterNotFound, got %v", err)
+	}
+	_, err = s.GetParameter(&ssm.GetParameterInput{Name: aws.String("/House/Stark/AryaStark:3")})
+	if errorCode(err) != ssm.ErrCodeParameterVersionNotFound {
+		t.Fatalf("expected ParameterVersionNotFound, got %v", err)
+	}
+
+	_, err = s.PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/House/Stark/JonSnow"),
+		Value: aws.String("King in the North"),
+		Type:  aws.String("SecureString"),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	get, err := s.GetParameters(&ssm.GetParametersInput{
+		Names: aws.StringSlice([]string{"/House/Stark/JonSnow", "/House/Stark/RobbStark"}),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(get.Parameters) != 1 || len(get.InvalidParameters) != 1 {
+		t.Fatalf("expected 1 parameter and 1 invalid parameter, got %+v", get)
+	}
+	if aws.StringValue(get.Parameters[0].Value) == "King in the North" {
+		t.Fatal("expected a SecureString value not to be decrypted")
+	}
+
+	for _, name := range []string{"House/Stark", "/aws/House", "/House//Stark", "/House/Stark/"} {
+		_, err = s.PutParameter(&ssm.PutParameterInput{Name: aws.String(name), Value: aws.String("v"), Type: aws.String("String")})
+		if errorCode(err) != fake.ErrCodeValidationException {
+			t.Fatalf("expected ValidationException for %s, got %v", name, err)
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	s := fake.New("region")
+	for i := 0; i < 25; i++ {
+		put(t, s, fmt.Sprintf("/House/Frey/Son%02d", i), "Frey", false)
+	}
+	put(t, s, "/House/Frey/Twins/WalderFrey", "Lord", false)
+
+	pages := 0
+	var names []string
+	input := &ssm.GetParametersByPathInput{Path: aws.String("/House/Frey")}
+	for {
+		resp, err := s.GetParametersByPath(input)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		pages++
+		if len(resp.Parameters) > 10 {
+			t.Fatalf("expected at most 10 results, got %d", len(resp.Parameters))
+		}
+		for _, p := range resp.Parameters {
+			names = append(names, aws.StringValue(p.Name))
+		}
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	if pages != 3 || len(names) != 25 {
+		t.Fatalf("expected 25 parameters in 3 pages, got %d in %d", len(names), pages)
+	}
+
+	input = &ssm.GetParametersByPathInput{Path: aws.String("/House"), Recursive: aws.Bool(true), MaxResults: aws.Int64(10)}
+	count := 0
+	for {
+		resp, err := s.GetParametersByPath(input)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		count += len(resp.Parameters)
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	if count != 26 {
+		t.Fatalf("expected 26 parameters recursively, got %d", count)
+	}
+	_, err := s.GetParametersByPath(&ssm.GetParametersByPathInput{Path: aws.String("/House"), MaxResults: aws.Int64(11)})
+	if errorCode(err) != fake.ErrCodeValidationException {
+		t.Fatalf("expected ValidationException for more than 10 results, got %v", err)
+	}
+	_, err = s.GetParametersByPath(&ssm.GetParametersByPathInput{Path: aws.String("/House"), NextToken: aws.String("bogus")})
+	if errorCode(err) != ssm.ErrCodeInvalidNextToken {
+		t.Fatalf("expected InvalidNextToken, got %v", err)
+	}
+
+	described, err := s.DescribeParameters(&ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: aws.StringSlice([]string{"/House/Frey"})},
+			{Key: aws.String("Name"), Option: aws.String("BeginsWith"), Values: aws.StringSlice([]string{"/House/Frey/Twins"})},
+		},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(described.Parameters) != 1 || aws.StringValue(described.Parameters[0].Name) != "/House/Frey/Twins/WalderFrey" {
+		t.Fatalf("expected WalderFrey, got %+v", described.Parameters)
+	}
+	_, err = s.DescribeParameters(&ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{{Key: aws.String("Label"), Values: aws.StringSlice([]string{"x"})}},
+	})
+	if errorCode(err) != ssm.ErrCodeInvalidFilterKey {
+		t.Fatalf("expected InvalidFilterKey, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	s := fake.New("region")
+	put(t, s, "/House/Bolton/RamsayBolton", "Bastard", false)
+	resp, err := s.DeleteParameters(&ssm.DeleteParametersInput{
+		Names: aws.StringSlice([]string{"/House/Bolton/RamsayBolton", "/House/Bolton/RooseBolton"}),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(resp.DeletedParameters) != 1 || aws.StringValue(resp.InvalidParameters[0]) != "/House/Bolton/RooseBolton" {
+		t.Fatalf("expected 1 deleted and 1 invalid parameter, got %+v", resp)
+	}
+	_, err = s.DeleteParameter(&ssm.DeleteParameterInput{Name: aws.String("/House/Bolton/RamsayBolton")})
+	if errorCode(err) != ssm.ErrCodeParameterNotFound {
+		t.Fatalf("expected ParameterNotFound, got %v", err)
+	}
+	if v := put(t, s, "/House/Bolton/RamsayBolton", "Bolton", false); v != 1 {
+		t.Fatalf("expected a new parameter to start at version 1, got %d", v)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	s := fake.New("region")
+	name := "/House/Lannister/JaimeLannister"
+	put(t, s, name, "Kingslayer", false)
+	put(t, s, name, "Lord Commander", true)
+
+	resp, err := s.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:             aws.String(name),
+		ParameterVersion: aws.Int64(1),
+		Labels:           aws.StringSlice([]string{"season1"}),
+	})
+	if err != nil || aws.Int64Value(resp.ParameterVersion) != 1 {
+		t.Fatalf("expected version 1 to be labeled, got %+v, %v", resp, err)
+	}
+	resp, err = s.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:   aws.String(name),
+		Labels: aws.StringSlice([]string{"season1", "1bad", "awsLabel"}),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(resp.InvalidLabels) != 2 {
+		t.Fatalf("expected 2 invalid labels, got %v", aws.StringValueSlice(resp.InvalidLabels))
+	}
+	_, err = s.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:   aws.String(name),
+		Labels: aws.StringSlice([]string{"season1"}),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	get, err := s.GetParameter(&ssm.GetParameterInput{Name: aws.String(name + ":season1")})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.Int64Value(get.Parameter.Version) != 2 {
+		t.Fatalf("expected the label to move to version 2, got version %d", aws.Int64Value(get.Parameter.Version))
+	}
+
+	unlabel, err := s.UnlabelParameterVersion(&ssm.UnlabelParameterVersionInput{
+		Name:             aws.String(name),
+		ParameterVersion: aws.Int64(2),
+		Labels:           aws.StringSlice([]string{"season1", "season2"}),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(unlabel.RemovedLabels) != 1 || len(unlabel.InvalidLabels) != 1 {
+		t.Fatalf("expected 1 removed and 1 invalid label, got %+v", unlabel)
+	}
+	byLabel, err := s.GetParametersByPath(&ssm.GetParametersByPathInput{
+		Path: aws.String("/House/Lannister"),
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			{Key: aws.String("Label"), Option: aws.String("Equals"), Values: aws.StringSlice([]string{"season1"})},
+		},
+	})
+	if err != nil || len(byLabel.Parameters) != 0 {
+		t.Fatalf("expected no labeled parameters, got %+v, %v", byLabel, err)
+	}
+}
+
+func TestTagsAndTiers(t *testing.T) {
+	s := fake.New("region")
+	name := "/House/Targaryen/DaenerysTargaryen"
+	_, err := s.PutParameter(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String("Mother of Dragons"),
+		Type:      aws.String("String"),
+		Overwrite: aws.Bool(true),
+		Tags:      []*ssm.Tag{{Key: aws.String("Dragons"), Value: aws.String("3")}},
+	})
+	if errorCode(err) != fake.ErrCodeValidationException {
+		t.Fatalf("expected ValidationException for tags with overwrite, got %v", err)
+	}
+	_, err = s.PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String(name),
+		Value: aws.String("Mother of Dragons"),
+		Type:  aws.String("String"),
+		Tags:  []*ssm.Tag{{Key: aws.String("Dragons"), Value: aws.String("3")}},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = s.AddTagsToResource(&ssm.AddTagsToResourceInput{
+		ResourceId:   aws.String(name),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+		Tags:         []*ssm.Tag{{Key: aws.String("Dragons"), Value: aws.String("2")}, {Key: aws.String("Title"), Value: aws.String("Queen")}},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	tags, err := s.ListTagsForResource(&ssm.ListTagsForResourceInput{
+		ResourceId:   aws.String(name),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(tags.TagList) != 2 || aws.StringValue(tags.TagList[0].Value) != "2" {
+		t.Fatalf("expected 2 tags with Dragons=2, got %+v", tags.TagList)
+	}
+	_, err = s.ListTagsForResource(&ssm.ListTagsForResourceInput{
+		ResourceId:   aws.String("/House/Targaryen/ViserysTargaryen"),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	})
+	if errorCode(err) != ssm.ErrCodeInvalidResourceId {
+		t.Fatalf("expected InvalidResourceId, got %v", err)
+	}
+
+	policies := `[{"Type":"Expiration","Version":"1.0","Attributes":{"Timestamp":"2030-01-01T00:00:00.000Z"}}]`
+	_, err = s.PutParameter(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String("Queen"),
+		Overwrite: aws.Bool(true),
+		Policies:  aws.String(policies),
+	})
+	if errorCode(err) != ssm.ErrCodeIncompatiblePolicyException {
+		t.Fatalf("expected IncompatiblePolicyException for a standard parameter with policies, got %v", err)
+	}
+	resp, err := s.PutParameter(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(strings.Repeat("Dracarys", 600)),
+		Overwrite: aws.Bool(true),
+		Tier:      aws.String(ssm.ParameterTierIntelligentTiering),
+		Policies:  aws.String(policies),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.StringValue(resp.Tier) != ssm.ParameterTierAdvanced {
+		t.Fatalf("expected the advanced tier, got %s", aws.StringValue(resp.Tier))
+	}
+	_, err = s.PutParameter(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String("Queen"),
+		Overwrite: aws.Bool(true),
+		Tier:      aws.String(ssm.ParameterTierStandard),
+	})
+	if errorCode(err) != fake.ErrCodeValidationException {
+		t.Fatalf("expected ValidationException downgrading the tier, got %v", err)
+	}
+	history, err := s.GetParameterHistory(&ssm.GetParameterHistoryInput{Name: aws.String(name)})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	latest := history.Parameters[len(history.Parameters)-1]
+	if len(history.Parameters) != 2 || len(latest.Policies) != 1 || aws.StringValue(latest.Policies[0].PolicyType) != "Expiration" {
+		t.Fatalf("expected 2 versions with a policy on the latest, got %+v", history.Parameters)
+	}
+}
+
+func TestServer(t *testing.T) {
+	regions := fake.NewRegions()
+	server := httptest.NewServer(fake.NewServer(regions))
+	defer server.Close()
+	client := ssm.New(session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	})))
+
+	_, err := client.PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/House/Stark/AryaStark"),
+		Value: aws.String("No one"),
+		Type:  aws.String("SecureString"),
+		Tags:  []*ssm.Tag{{Key: aws.String("Faceless"), Value: aws.String("true")}},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	resp, err := client.GetParameterHistory(&ssm.GetParameterHistoryInput{
+		Name:           aws.String("/House/Stark/AryaStark"),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	want, err := regions.Store("us-west-2").GetParameterHistory(&ssm.GetParameterHistoryInput{
+		Name:           aws.String("/House/Stark/AryaStark"),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(resp.Parameters) != 1 {
+		t.Fatalf("expected one version, got %v", resp.Parameters)
+	}
+	got, expected := resp.Parameters[0], want.Parameters[0]
+	if aws.StringValue(got.Value) != "No one" || aws.StringValue(got.KeyId) != aws.StringValue(expected.KeyId) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if !aws.TimeValue(got.LastModifiedDate).Equal(aws.TimeValue(expected.LastModifiedDate)) {
+		t.Errorf("expected last modified date %s, got %s", expected.LastModifiedDate, got.LastModifiedDate)
+	}
+
+	tags, err := client.ListTagsForResource(&ssm.ListTagsForResourceInput{
+		ResourceId:   aws.String("/House/Stark/AryaStark"),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(tags.TagList) != 1 || aws.StringValue(tags.TagList[0].Key) != "Faceless" {
+		t.Errorf("expected the Faceless tag, got %v", tags.TagList)
+	}
+
+	_, err = client.GetParameter(&ssm.GetParameterInput{Name: aws.String("/House/Stark/RickonStark")})
+	if errorCode(err) != ssm.ErrCodeParameterNotFound {
+		t.Errorf("expected %s, got %v", ssm.ErrCodeParameterNotFound, err)
+	}
+	if _, err := regions.Store(fake.DefaultRegion).GetParameter(&ssm.GetParameterInput{
+		Name: aws.String("/House/Stark/AryaStark"),
+	}); errorCode(err) != ssm.ErrCodeParameterNotFound {
+		t.Errorf("expected requests to be served by the store of their region, got %v", err)
+	}
+}
+
+func TestKMS(t *testing.T) {
+	regions := fake.NewRegions()
+	k := regions.KMSClient("us-east-1", "")
+	keys, err := k.ListKeys(&kms.ListKeysInput{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	arn := "arn:aws:kms:us-east-1:" + fake.DefaultAccount + ":key/" + fake.DefaultKMSKeyID
+	if len(keys.Keys) != 1 || aws.StringValue(keys.Keys[0].KeyArn) != arn {
+		t.Fatalf("unexpected keys %+v", keys.Keys)
+	}
+
+	dataKey, err := k.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:   aws.String(arn),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(dataKey.Plaintext) != 32 {
+		t.Fatalf("expected a 32 byte data key, got %d", len(dataKey.Plaintext))
+	}
+	decrypted, err := k.Decrypt(&kms.DecryptInput{KeyId: aws.String(fake.DefaultKMSKeyID), CiphertextBlob: dataKey.CiphertextBlob})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if string(decrypted.Plaintext) != string(dataKey.Plaintext) {
+		t.Fatal("expected the data key to be unwrapped")
+	}
+
+	_, err = k.GenerateDataKey(&kms.GenerateDataKeyInput{KeyId: aws.String("alias/missing"), KeySpec: aws.String(kms.DataKeySpecAes256)})
+	if errorCode(err) != kms.ErrCodeNotFoundException {
+		t.Fatalf("expected %s, got %v", kms.ErrCodeNotFoundException, err)
+	}
+	_, err = k.Decrypt(&kms.DecryptInput{CiphertextBlob: []byte("garbage")})
+	if errorCode(err) != kms.ErrCodeInvalidCiphertextException {
+		t.Fatalf("expected %s, got %v", kms.ErrCodeInvalidCiphertextException, err)
+	}
+	// Keys are regional
+	_, err = regions.KMSClient("us-west-2", "").Decrypt(&kms.DecryptInput{
+		KeyId:          aws.String(arn),
+		CiphertextBlob: dataKey.CiphertextBlob,
+	})
+	if errorCode(err) != kms.ErrCodeNotFoundException {
+		t.Fatalf("expected %s, got %v", kms.ErrCodeNotFoundException, err)
+	}
+}
+
+func TestSTS(t *testing.T) {
+	resp, err := fake.NewRegions().STSClient("us-east-1", "").GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.StringValue(resp.Arn) != fake.DefaultUser || aws.StringValue(resp.Account) != fake.DefaultAccount {
+		t.Fatalf("unexpected identity %+v", resp)
+	}
+}
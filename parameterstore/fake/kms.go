@@ -0,0 +1,111 @@
+package fake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// DefaultKMSKeyID is the key that exists in every region of the fake KMS
+const DefaultKMSKeyID = "1234abcd-12ab-34cd-56ef-1234567890ab"
+
+// KMS is an in-memory stand-in for the KMS operations that ssmsh uses. Data keys are wrapped
+// with the ID of the key rather than encrypted, so they are not protected. Operations that
+// ssmsh does not use panic through the embedded nil interface.
+type KMS struct {
+	kmsiface.KMSAPI
+	Region  string   // Used in ARNs
+	Account string   // Used in ARNs
+	Keys    []string // The IDs of the keys that exist
+}
+
+// NewKMS creates a key store for a region with the default key
+func NewKMS(region string) *KMS {
+	return &KMS{
+		Region:  region,
+		Account: DefaultAccount,
+		Keys:    []string{DefaultKMSKeyID},
+	}
+}
+
+// arn returns the ARN of a key
+func (k *KMS) arn(id string) string {
+	return fmt.Sprintf("arn:aws:kms:%s:%s:key/%s", k.Region, k.Account, id)
+}
+
+// lookup returns the ID of a key given its ID or ARN
+func (k *KMS) lookup(keyID string) (string, error) {
+	for _, id := range k.Keys {
+		if keyID == id || keyID == k.arn(id) {
+			return id, nil
+		}
+	}
+	return "", newError(kms.ErrCodeNotFoundException, "Key '%s' does not exist", keyID)
+}
+
+// ListKeys returns every key in one page
+func (k *KMS) ListKeys(in *kms.ListKeysInput) (*kms.ListKeysOutput, error) {
+	out := &kms.ListKeysOutput{Truncated: aws.Bool(false)}
+	for _, id := range k.Keys {
+		out.Keys = append(out.Keys, &kms.KeyListEntry{KeyId: aws.String(id), KeyArn: aws.String(k.arn(id))})
+	}
+	return out, nil
+}
+
+// GenerateDataKey returns a random data key and the key wrapped by a KMS key
+func (k *KMS) GenerateDataKey(in *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	id, err := k.lookup(aws.StringValue(in.KeyId))
+	if err != nil {
+		return nil, err
+	}
+	var size int
+	switch {
+	case aws.StringValue(in.KeySpec) == kms.DataKeySpecAes256:
+		size = 32
+	case aws.StringValue(in.KeySpec) == kms.DataKeySpecAes128:
+		size = 16
+	case in.NumberOfBytes != nil && aws.Int64Value(in.NumberOfBytes) >= 1 && aws.Int64Value(in.NumberOfBytes) <= 1024:
+		size = int(aws.Int64Value(in.NumberOfBytes))
+	default:
+		return nil, newError(ErrCodeValidationException, "Either KeySpec or NumberOfBytes is required")
+	}
+	plaintext := make([]byte, size)
+	_, err = rand.Read(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          aws.String(k.arn(id)),
+		Plaintext:      plaintext,
+		CiphertextBlob: append([]byte(id+selectorDelimiter), plaintext...),
+	}, nil
+}
+
+// Decrypt unwraps a data key. If a key is given, it must be the key that wrapped the data key.
+func (k *KMS) Decrypt(in *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	parts := bytes.SplitN(in.CiphertextBlob, []byte(selectorDelimiter), 2)
+	if len(parts) != 2 {
+		return nil, newError(kms.ErrCodeInvalidCiphertextException, "The ciphertext is invalid")
+	}
+	id, err := k.lookup(string(parts[0]))
+	if err != nil {
+		return nil, newError(kms.ErrCodeInvalidCiphertextException, "The ciphertext is invalid")
+	}
+	if in.KeyId != nil {
+		requested, err := k.lookup(aws.StringValue(in.KeyId))
+		if err != nil {
+			return nil, err
+		}
+		if requested != id {
+			return nil, newError(kms.ErrCodeIncorrectKeyException, "The key ID in the request does not identify the key that encrypted the ciphertext")
+		}
+	}
+	return &kms.DecryptOutput{
+		KeyId:     aws.String(k.arn(id)),
+		Plaintext: parts[1],
+	}, nil
+}
@@ -0,0 +1,100 @@
+package fake
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+var labelRegexp = regexp.MustCompile(`^[a-zA-Z_.\-][a-zA-Z0-9_.\-]{0,99}$`)
+
+// validLabel checks a label against the rules of the service
+func validLabel(label string) bool {
+	lower := strings.ToLower(label)
+	return labelRegexp.MatchString(label) && !strings.HasPrefix(lower, "aws") && !strings.HasPrefix(lower, "ssm")
+}
+
+// LabelParameterVersion attaches labels to a version of a parameter, the latest if none is
+// given, moving them from any other version. Labels that are not valid are returned as invalid.
+func (s *SSM) LabelParameterVersion(in *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := aws.StringValue(in.Name)
+	p, ok := s.parameters[name]
+	if !ok {
+		return nil, newError(ssm.ErrCodeParameterNotFound, "Parameter %s not found.", name)
+	}
+	h := p.latest()
+	if in.ParameterVersion != nil {
+		h = p.version(strconv.FormatInt(aws.Int64Value(in.ParameterVersion), 10))
+		if h == nil {
+			return nil, newError(ssm.ErrCodeParameterVersionNotFound, "Systems Manager could not find version %d of %s.",
+				aws.Int64Value(in.ParameterVersion), name)
+		}
+	}
+	out := &ssm.LabelParameterVersionOutput{ParameterVersion: h.Version}
+	var valid []string
+	for _, l := range aws.StringValueSlice(in.Labels) {
+		if !validLabel(l) {
+			out.InvalidLabels = append(out.InvalidLabels, aws.String(l))
+			continue
+		}
+		if !contains(aws.StringValueSlice(h.Labels), l) {
+			valid = append(valid, l)
+		}
+	}
+	if len(out.InvalidLabels) > 0 {
+		return out, nil
+	}
+	if len(h.Labels)+len(valid) > maxLabelsPerVersion {
+		return nil, newError(ssm.ErrCodeParameterVersionLabelLimitExceeded, "A parameter version can have at most %d labels", maxLabelsPerVersion)
+	}
+	for _, other := range p.History {
+		other.Labels = aws.StringSlice(without(aws.StringValueSlice(other.Labels), valid))
+	}
+	h.Labels = append(h.Labels, aws.StringSlice(valid)...)
+	return out, nil
+}
+
+// UnlabelParameterVersion removes labels from a version of a parameter. Labels that are not
+// attached to the version are returned as invalid.
+func (s *SSM) UnlabelParameterVersion(in *ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := aws.StringValue(in.Name)
+	p, ok := s.parameters[name]
+	if !ok {
+		return nil, newError(ssm.ErrCodeParameterNotFound, "Parameter %s not found.", name)
+	}
+	h := p.version(strconv.FormatInt(aws.Int64Value(in.ParameterVersion), 10))
+	if h == nil {
+		return nil, newError(ssm.ErrCodeParameterVersionNotFound, "Systems Manager could not find version %d of %s.",
+			aws.Int64Value(in.ParameterVersion), name)
+	}
+	out := &ssm.UnlabelParameterVersionOutput{}
+	attached := aws.StringValueSlice(h.Labels)
+	var removed []string
+	for _, l := range aws.StringValueSlice(in.Labels) {
+		if contains(attached, l) {
+			removed = append(removed, l)
+			out.RemovedLabels = append(out.RemovedLabels, aws.String(l))
+		} else {
+			out.InvalidLabels = append(out.InvalidLabels, aws.String(l))
+		}
+	}
+	h.Labels = aws.StringSlice(without(attached, removed))
+	return out, nil
+}
+
+// without returns the strings that are not in a set of strings to remove
+func without(values, remove []string) (r []string) {
+	for _, v := range values {
+		if !contains(remove, v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}
@@ -0,0 +1,409 @@
+package fake
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// policyTypes are the types of parameter policies
+var policyTypes = map[string]bool{
+	"Expiration":             true,
+	"ExpirationNotification": true,
+	"NoChangeNotification":   true,
+}
+
+// GetParameter returns the latest or selected version of a parameter
+func (s *SSM) GetParameter(in *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := s.lookup(aws.StringValue(in.Name))
+	if err != nil {
+		return nil, err
+	}
+	_, selector := splitSelector(aws.StringValue(in.Name))
+	return &ssm.GetParameterOutput{Parameter: s.parameter(h, selector, aws.BoolValue(in.WithDecryption))}, nil
+}
+
+// GetParameters returns up to 10 parameters. Names that are not found are returned as invalid.
+func (s *SSM) GetParameters(in *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(in.Names) == 0 || len(in.Names) > maxNames {
+		return nil, newError(ErrCodeValidationException, "Names must contain 1 to %d names", maxNames)
+	}
+	out := &ssm.GetParametersOutput{}
+	for _, n := range in.Names {
+		h, err := s.lookup(aws.StringValue(n))
+		if err != nil {
+			out.InvalidParameters = append(out.InvalidParameters, n)
+			continue
+		}
+		_, selector := splitSelector(aws.StringValue(n))
+		out.Parameters = append(out.Parameters, s.parameter(h, selector, aws.BoolValue(in.WithDecryption)))
+	}
+	return out, nil
+}
+
+// GetParametersByPath returns the parameters beneath a path, 10 at a time. Filters by type,
+// key and label are supported. With a label filter, the labeled version is returned.
+func (s *SSM) GetParametersByPath(in *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := aws.StringValue(in.Path)
+	if !strings.HasPrefix(path, delimiter) {
+		return nil, newError(ErrCodeValidationException, "The path %q must begin with a forward slash", path)
+	}
+	var label string
+	var filters []*ssm.ParameterStringFilter
+	for _, f := range in.ParameterFilters {
+		switch aws.StringValue(f.Key) {
+		case "Type", "KeyId":
+			filters = append(filters, f)
+		case "Label":
+			if len(f.Values) != 1 {
+				return nil, newError(ssm.ErrCodeInvalidFilterValue, "The Label filter requires one value")
+			}
+			label = aws.StringValue(f.Values[0])
+		default:
+			return nil, newError(ssm.ErrCodeInvalidFilterKey, "The filter key %s is not valid for GetParametersByPath", aws.StringValue(f.Key))
+		}
+	}
+
+	var matches []*ssm.Parameter
+	for _, name := range s.names() {
+		if !beneath(name, path, aws.BoolValue(in.Recursive)) {
+			continue
+		}
+		p := s.parameters[name]
+		h := p.latest()
+		if label != "" {
+			if h = p.version(label); h == nil {
+				continue
+			}
+		}
+		if !matchFilters(filters, h, p.Tags) {
+			continue
+		}
+		matches = append(matches, s.parameter(h, "", aws.BoolValue(in.WithDecryption)))
+	}
+	start, end, next, err := page(len(matches), in.NextToken, in.MaxResults, maxPathResults)
+	if err != nil {
+		return nil, err
+	}
+	return &ssm.GetParametersByPathOutput{Parameters: matches[start:end], NextToken: next}, nil
+}
+
+// beneath reports whether a name is directly beneath a path or, when recursive, at any depth beneath it
+func beneath(name, path string, recursive bool) bool {
+	prefix := strings.TrimSuffix(path, delimiter) + delimiter
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	return recursive || !strings.Contains(strings.TrimPrefix(name, prefix), delimiter)
+}
+
+// GetParameterHistory returns every version of a parameter, oldest first, 50 at a time
+func (s *SSM) GetParameterHistory(in *ssm.GetParameterHistoryInput) (*ssm.GetParameterHistoryOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := aws.StringValue(in.Name)
+	p, ok := s.parameters[name]
+	if !ok {
+		return nil, newError(ssm.ErrCodeParameterNotFound, "Parameter %s not found.", name)
+	}
+	start, end, next, err := page(len(p.History), in.NextToken, in.MaxResults, maxHistoryResults)
+	if err != nil {
+		return nil, err
+	}
+	out := &ssm.GetParameterHistoryOutput{NextToken: next}
+	for _, h := range p.History[start:end] {
+		out.Parameters = append(out.Parameters, copyHistory(h, aws.BoolValue(in.WithDecryption)))
+	}
+	return out, nil
+}
+
+// PutParameter creates a parameter or, with overwrite, adds a new version of an existing one
+func (s *SSM) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := aws.StringValue(in.Name)
+	err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	if in.Value == nil || aws.StringValue(in.Value) == "" {
+		return nil, newError(ErrCodeValidationException, "A value is required for %s", name)
+	}
+	value := aws.StringValue(in.Value)
+	p, exists := s.parameters[name]
+	if exists && !aws.BoolValue(in.Overwrite) {
+		return nil, newError(ssm.ErrCodeParameterAlreadyExists, "The parameter already exists. To overwrite this value, set the overwrite option in the request to true.")
+	}
+	if aws.BoolValue(in.Overwrite) && len(in.Tags) > 0 {
+		return nil, newError(ErrCodeValidationException, "Invalid request: tags and overwrite can't be used together. To create a parameter with tags, please remove overwrite flag. To update tags for an existing parameter, please use AddTagsToResource or RemoveTagsFromResource.")
+	}
+	if len(in.Tags) > maxTags {
+		return nil, newError(ssm.ErrCodeTooManyTagsError, "A parameter can have at most %d tags", maxTags)
+	}
+
+	var previous *ssm.ParameterHistory
+	if exists {
+		previous = p.latest()
+	}
+	parameterType := aws.StringValue(in.Type)
+	switch {
+	case parameterType == "" && previous != nil:
+		parameterType = aws.StringValue(previous.Type)
+	case parameterType == "":
+		return nil, newError(ErrCodeValidationException, "A type is required for %s", name)
+	case parameterType != ssm.ParameterTypeString && parameterType != ssm.ParameterTypeStringList &&
+		parameterType != ssm.ParameterTypeSecureString:
+		return nil, newError(ssm.ErrCodeUnsupportedParameterType, "The parameter type %s is not supported", parameterType)
+	}
+	if pattern := aws.StringValue(in.AllowedPattern); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, newError(ssm.ErrCodeInvalidAllowedPatternException, "The allowed pattern %s is not valid: %v", pattern, err)
+		}
+		if !re.MatchString(value) {
+			return nil, newError(ssm.ErrCodeParameterPatternMismatchException, "Parameter value, cannot be validated against allowedPattern: %s", pattern)
+		}
+	}
+	policies, err := parsePolicies(aws.StringValue(in.Policies))
+	if err != nil {
+		return nil, err
+	}
+	tier, err := selectTier(aws.StringValue(in.Tier), previous, len(value), len(policies) > 0)
+	if err != nil {
+		return nil, err
+	}
+	dataType := aws.StringValue(in.DataType)
+	if dataType == "" {
+		dataType = "text"
+	}
+
+	h := &ssm.ParameterHistory{
+		Name:             aws.String(name),
+		Type:             aws.String(parameterType),
+		Value:            aws.String(value),
+		Description:      in.Description,
+		AllowedPattern:   in.AllowedPattern,
+		DataType:         aws.String(dataType),
+		Tier:             aws.String(tier),
+		Policies:         policies,
+		LastModifiedDate: now(),
+		LastModifiedUser: aws.String(s.User),
+		Version:          aws.Int64(1),
+	}
+	if parameterType == ssm.ParameterTypeSecureString {
+		h.KeyId = in.KeyId
+		if aws.StringValue(h.KeyId) == "" {
+			h.KeyId = aws.String(DefaultKeyID)
+		}
+	}
+	if !exists {
+		p = &Parameter{Tags: make(map[string]string)}
+		for _, t := range in.Tags {
+			p.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+		s.parameters[name] = p
+	} else {
+		h.Version = aws.Int64(aws.Int64Value(previous.Version) + 1)
+		if len(p.History) == maxVersions {
+			if len(p.History[0].Labels) > 0 {
+				return nil, newError(ssm.ErrCodeParameterMaxVersionLimitExceeded,
+					"The oldest version of %s can't be deleted because it has a label associated with it", name)
+			}
+			p.History = p.History[1:]
+		}
+	}
+	p.History = append(p.History, h)
+	return &ssm.PutParameterOutput{Version: h.Version, Tier: h.Tier}, nil
+}
+
+// parsePolicies converts the JSON array of policies given to PutParameter
+func parsePolicies(text string) ([]*ssm.ParameterInlinePolicy, error) {
+	if text == "" {
+		return nil, nil
+	}
+	var raw []json.RawMessage
+	err := json.Unmarshal([]byte(text), &raw)
+	if err != nil {
+		return nil, newError(ssm.ErrCodeInvalidPolicyTypeException, "The policies are not a JSON array: %v", err)
+	}
+	var policies []*ssm.ParameterInlinePolicy
+	for _, r := range raw {
+		var policy struct{ Type string }
+		err = json.Unmarshal(r, &policy)
+		if err != nil || !policyTypes[policy.Type] {
+			return nil, newError(ssm.ErrCodeInvalidPolicyTypeException, "The policy %s is not valid", string(r))
+		}
+		policies = append(policies, &ssm.ParameterInlinePolicy{
+			PolicyText:   aws.String(string(r)),
+			PolicyType:   aws.String(policy.Type),
+			PolicyStatus: aws.String("Pending"),
+		})
+	}
+	return policies, nil
+}
+
+// selectTier determines the tier of a new version. Advanced parameters cannot be downgraded,
+// and values over 4KB and policies require the advanced tier.
+func selectTier(requested string, previous *ssm.ParameterHistory, size int, hasPolicies bool) (string, error) {
+	if size > advancedValueSize {
+		return "", newError(ErrCodeValidationException, "The value is %d bytes, larger than the maximum of %d", size, advancedValueSize)
+	}
+	wasAdvanced := previous != nil && aws.StringValue(previous.Tier) == ssm.ParameterTierAdvanced
+	needsAdvanced := wasAdvanced || size > standardValueSize || hasPolicies
+	switch requested {
+	case ssm.ParameterTierAdvanced:
+		return ssm.ParameterTierAdvanced, nil
+	case ssm.ParameterTierIntelligentTiering:
+		if needsAdvanced {
+			return ssm.ParameterTierAdvanced, nil
+		}
+		return ssm.ParameterTierStandard, nil
+	case ssm.ParameterTierStandard, "":
+		switch {
+		case wasAdvanced && requested == "":
+			return ssm.ParameterTierAdvanced, nil
+		case wasAdvanced:
+			return "", newError(ErrCodeValidationException, "This parameter uses the advanced-parameter tier. You can't downgrade a parameter from the advanced-parameter tier to the standard-parameter tier.")
+		case size > standardValueSize:
+			return "", newError(ErrCodeValidationException, "Standard tier parameters support a maximum parameter value of %d bytes", standardValueSize)
+		case hasPolicies:
+			return "", newError(ssm.ErrCodeIncompatiblePolicyException, "Policies are only supported for advanced tier parameters")
+		}
+		return ssm.ParameterTierStandard, nil
+	}
+	return "", newError(ErrCodeValidationException, "The tier %s is not valid", requested)
+}
+
+// DeleteParameter deletes a parameter and all of its versions
+func (s *SSM) DeleteParameter(in *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := aws.StringValue(in.Name)
+	if _, ok := s.parameters[name]; !ok {
+		return nil, newError(ssm.ErrCodeParameterNotFound, "Parameter %s not found.", name)
+	}
+	delete(s.parameters, name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+// DeleteParameters deletes up to 10 parameters. Names that are not found are returned as invalid.
+func (s *SSM) DeleteParameters(in *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(in.Names) == 0 || len(in.Names) > maxNames {
+		return nil, newError(ErrCodeValidationException, "Names must contain 1 to %d names", maxNames)
+	}
+	out := &ssm.DeleteParametersOutput{}
+	for _, n := range in.Names {
+		name := aws.StringValue(n)
+		if _, ok := s.parameters[name]; !ok {
+			out.InvalidParameters = append(out.InvalidParameters, n)
+			continue
+		}
+		delete(s.parameters, name)
+		out.DeletedParameters = append(out.DeletedParameters, n)
+	}
+	return out, nil
+}
+
+// DescribeParameters returns the metadata of the latest version of parameters that match
+// the filters, 10 at a time unless more are requested. Filters by path, name, type, key,
+// tier, data type and tag are supported.
+func (s *SSM) DescribeParameters(in *ssm.DescribeParametersInput) (*ssm.DescribeParametersOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range in.ParameterFilters {
+		key := aws.StringValue(f.Key)
+		switch {
+		case key == "Path", key == "Name", key == "Type", key == "KeyId", key == "Tier", key == "DataType":
+		case strings.HasPrefix(key, "tag:"):
+		default:
+			return nil, newError(ssm.ErrCodeInvalidFilterKey, "The filter key %s is not valid for DescribeParameters", key)
+		}
+		if len(f.Values) > maxFilterValues {
+			return nil, newError(ssm.ErrCodeInvalidFilterValue, "The filter %s has more than %d values", key, maxFilterValues)
+		}
+	}
+	var matches []*ssm.ParameterMetadata
+	for _, name := range s.names() {
+		p := s.parameters[name]
+		if matchFilters(in.ParameterFilters, p.latest(), p.Tags) {
+			matches = append(matches, metadata(p.latest()))
+		}
+	}
+	maxResults := in.MaxResults
+	if maxResults == nil {
+		maxResults = aws.Int64(maxPathResults)
+	}
+	start, end, next, err := page(len(matches), in.NextToken, maxResults, maxDescribeResults)
+	if err != nil {
+		return nil, err
+	}
+	return &ssm.DescribeParametersOutput{Parameters: matches[start:end], NextToken: next}, nil
+}
+
+// matchFilters reports whether a version of a parameter matches every filter
+func matchFilters(filters []*ssm.ParameterStringFilter, h *ssm.ParameterHistory, tags map[string]string) bool {
+	for _, f := range filters {
+		key := aws.StringValue(f.Key)
+		option := aws.StringValue(f.Option)
+		values := aws.StringValueSlice(f.Values)
+		var ok bool
+		switch {
+		case key == "Path":
+			recursive := option == "Recursive"
+			for _, v := range values {
+				ok = ok || beneath(aws.StringValue(h.Name), v, recursive)
+			}
+		case key == "Name":
+			for _, v := range values {
+				ok = ok || matchOption(option, aws.StringValue(h.Name), v)
+			}
+		case key == "Type":
+			ok = contains(values, aws.StringValue(h.Type))
+		case key == "KeyId":
+			ok = contains(values, aws.StringValue(h.KeyId))
+		case key == "Tier":
+			ok = contains(values, aws.StringValue(h.Tier))
+		case key == "DataType":
+			ok = contains(values, aws.StringValue(h.DataType))
+		case strings.HasPrefix(key, "tag:"):
+			v, tagged := tags[strings.TrimPrefix(key, "tag:")]
+			ok = tagged && (len(values) == 0 || contains(values, v))
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOption compares a name with a filter value using the filter's option
+func matchOption(option, name, value string) bool {
+	switch option {
+	case "BeginsWith":
+		return strings.HasPrefix(name, value)
+	case "Contains":
+		return strings.Contains(name, value)
+	default:
+		return name == value
+	}
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+const (
+	// DefaultRegion is the region of requests that are not signed for a region
+	DefaultRegion = "us-east-1"
+
+	targetPrefix = "AmazonSSM."
+	contentType  = "application/x-amz-json-1.1"
+)
+
+// operations are the SSM operations served over HTTP, and whether each modifies parameters
+var operations = map[string]bool{
+	"AddTagsToResource":       true,
+	"DeleteParameter":         true,
+	"DeleteParameters":        true,
+	"DescribeParameters":      false,
+	"GetParameter":            false,
+	"GetParameterHistory":     false,
+	"GetParameters":           false,
+	"GetParametersByPath":     false,
+	"LabelParameterVersion":   true,
+	"ListTagsForResource":     false,
+	"PutParameter":            true,
+	"RemoveTagsFromResource":  true,
+	"UnlabelParameterVersion": true,
+}
+
+// credentialRegexp finds the region in the credential scope of a signed request
+var credentialRegexp = regexp.MustCompile(`Credential=[^/]+/[^/]+/([^/]+)/`)
+
+// Server serves the SSM JSON 1.1 protocol from in-memory parameter stores, so that ssmsh and
+// any other SDK client can use the fake by setting its endpoint. Each request is served by the
+// store of the region it is signed for. Credentials and signatures are not checked.
+type Server struct {
+	Regions       *Regions
+	DefaultRegion string       // The region of requests that are not signed for a region
+	OnChange      func() error // Called after each request that modifies parameters, e.g. to save them
+
+	requests int64
+}
+
+// NewServer creates a server for a set of regional parameter stores
+func NewServer(regions *Regions) *Server {
+	return &Server{
+		Regions:       regions,
+		DefaultRegion: DefaultRegion,
+	}
+}
+
+// ServeHTTP handles a request with an X-Amz-Target header such as AmazonSSM.GetParameter
+func (srv *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Amzn-RequestId", strconv.FormatInt(atomic.AddInt64(&srv.requests, 1), 10))
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, newError("MethodNotAllowed", "%s is not supported", req.Method))
+		return
+	}
+	target := req.Header.Get("X-Amz-Target")
+	op := strings.TrimPrefix(target, targetPrefix)
+	mutating, ok := operations[op]
+	if !ok || !strings.HasPrefix(target, targetPrefix) {
+		writeError(w, http.StatusBadRequest, newError("UnknownOperationException", "Operation %q is not supported", target))
+		return
+	}
+
+	method := reflect.ValueOf(srv.Regions.Store(srv.region(req))).MethodByName(op)
+	input := reflect.New(method.Type().In(0).Elem())
+	// The fields of SSM inputs are named as in the protocol, so requests decode without help
+	err := json.NewDecoder(req.Body).Decode(input.Interface())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newError("SerializationException", "Unable to parse the request: %s", err))
+		return
+	}
+	results := method.Call([]reflect.Value{input})
+	if err, ok := results[1].Interface().(error); ok && err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	body, err := json.Marshal(toJSON(results[0]))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, newError("InternalServerError", "%s", err))
+		return
+	}
+	if mutating && srv.OnChange != nil {
+		err = srv.OnChange()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, newError("InternalServerError", "%s", err))
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// region returns the region a request is signed for
+func (srv *Server) region(req *http.Request) string {
+	m := credentialRegexp.FindStringSubmatch(req.Header.Get("Authorization"))
+	if m == nil {
+		return srv.DefaultRegion
+	}
+	return m[1]
+}
+
+// toJSON converts an SDK output to a value that encoding/json writes in the form the SDK reads:
+// fields named by their locationName, nil fields left out, and timestamps in seconds since the epoch
+func toJSON(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if t, ok := v.Interface().(*time.Time); ok {
+			// The SDK truncates timestamps to milliseconds after converting them from decimal,
+			// which can lose a millisecond, so half of one is added to survive the conversion
+			ms := t.UnixNano() / int64(time.Millisecond)
+			return (float64(ms) + 0.5) / 1e3
+		}
+		return toJSON(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" || isNil(v.Field(i)) {
+				continue
+			}
+			name := f.Tag.Get("locationName")
+			if name == "" {
+				name = f.Name
+			}
+			m[name] = toJSON(v.Field(i))
+		}
+		return m
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface() // Blobs are base64 encoded by encoding/json, as the SDK expects
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = toJSON(v.Index(i))
+		}
+		return s
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = toJSON(iter.Value())
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
+
+// isNil returns true for nil pointers, slices, maps and interfaces
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// writeError writes an error in the form the SDK expects, e.g. {"__type":"ParameterNotFound","message":"..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	code, message := "InternalServerError", err.Error()
+	if aerr, ok := err.(awserr.Error); ok {
+		code, message = aerr.Code(), aerr.Message()
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Amzn-ErrorType", code)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"__type":  code,
+		"message": message,
+	})
+}
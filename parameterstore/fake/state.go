@@ -0,0 +1,50 @@
+package fake
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// state is the saved form of the parameters in every region, keyed by region and then by name
+type state map[string]map[string]*Parameter
+
+// Save writes the parameters in every region as JSON
+func (r *Regions) Save(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := make(state)
+	for region, s := range r.stores {
+		s.mu.Lock()
+		st[region] = s.parameters
+		defer s.mu.Unlock()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(st)
+}
+
+// Load replaces the parameters in every region with those written by Save
+func (r *Regions) Load(rd io.Reader) error {
+	var st state
+	err := json.NewDecoder(rd).Decode(&st)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stores = make(map[string]*SSM)
+	for region, parameters := range st {
+		s := New(region)
+		for name, p := range parameters {
+			if p == nil || len(p.History) == 0 {
+				continue
+			}
+			if p.Tags == nil {
+				p.Tags = make(map[string]string)
+			}
+			s.parameters[name] = p
+		}
+		r.stores[region] = s
+	}
+	return nil
+}
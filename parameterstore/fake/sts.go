@@ -0,0 +1,39 @@
+package fake
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// STS is an in-memory stand-in for the STS operations that ssmsh uses. Operations that
+// ssmsh does not use panic through the embedded nil interface.
+type STS struct {
+	stsiface.STSAPI
+	User string // The ARN of the caller
+}
+
+// NewSTS creates an identity service whose caller is the default user
+func NewSTS() *STS {
+	return &STS{User: DefaultUser}
+}
+
+// GetCallerIdentity returns the caller
+func (s *STS) GetCallerIdentity(in *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(accountOf(s.User)),
+		Arn:     aws.String(s.User),
+		UserId:  aws.String("AIDACKCEVSQ6C2EXAMPLE"),
+	}, nil
+}
+
+// accountOf returns the account ID from an ARN
+func accountOf(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[4]
+}
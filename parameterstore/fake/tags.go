@@ -0,0 +1,77 @@
+package fake
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// tagged returns the parameter that a tagging call refers to
+func (s *SSM) tagged(resourceType, resourceID *string) (*Parameter, error) {
+	if aws.StringValue(resourceType) != ssm.ResourceTypeForTaggingParameter {
+		return nil, newError(ssm.ErrCodeInvalidResourceType, "The resource type %s is not supported", aws.StringValue(resourceType))
+	}
+	p, ok := s.parameters[aws.StringValue(resourceID)]
+	if !ok {
+		return nil, newError(ssm.ErrCodeInvalidResourceId, "The resource ID %s is not valid", aws.StringValue(resourceID))
+	}
+	return p, nil
+}
+
+// AddTagsToResource adds tags to a parameter, replacing the values of existing keys
+func (s *SSM) AddTagsToResource(in *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.tagged(in.ResourceType, in.ResourceId)
+	if err != nil {
+		return nil, err
+	}
+	added := 0
+	for _, t := range in.Tags {
+		if _, ok := p.Tags[aws.StringValue(t.Key)]; !ok {
+			added++
+		}
+	}
+	if len(p.Tags)+added > maxTags {
+		return nil, newError(ssm.ErrCodeTooManyTagsError, "A parameter can have at most %d tags", maxTags)
+	}
+	for _, t := range in.Tags {
+		p.Tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+// RemoveTagsFromResource removes tags from a parameter
+func (s *SSM) RemoveTagsFromResource(in *ssm.RemoveTagsFromResourceInput) (*ssm.RemoveTagsFromResourceOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.tagged(in.ResourceType, in.ResourceId)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range in.TagKeys {
+		delete(p.Tags, aws.StringValue(k))
+	}
+	return &ssm.RemoveTagsFromResourceOutput{}, nil
+}
+
+// ListTagsForResource returns the tags of a parameter, sorted by key
+func (s *SSM) ListTagsForResource(in *ssm.ListTagsForResourceInput) (*ssm.ListTagsForResourceOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.tagged(in.ResourceType, in.ResourceId)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for k := range p.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := &ssm.ListTagsForResourceOutput{}
+	for _, k := range keys {
+		out.TagList = append(out.TagList, &ssm.Tag{Key: aws.String(k), Value: aws.String(p.Tags[k])})
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package parameterstore
+
+import (
+	spath "path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// FindOptions are the criteria parameters must meet to be found. Empty criteria match everything.
+type FindOptions struct {
+	Name          string    // Glob matched against the last element of the name
+	Type          string    // Parameter type
+	KeyID         string    // KMS key ID, ARN or alias
+	Tier          string    // Parameter tier
+	Label         string    // A label attached to any version
+	Tags          []ssm.Tag // Tags that must be present with the given values
+	ModifiedSince time.Time // Modified at or after this time
+	ModifiedBy    string    // ARN or user name of the last modifier
+}
+
+// Find returns the metadata of the parameters beneath a path that meet all of the criteria,
+// sorted by name. Type, key, tier and tags are filtered by DescribeParameters, which does
+// not support labels, so those are found with GetParametersByPath.
+func (ps *ParameterStore) Find(path ParameterPath, opts FindOptions) ([]ssm.ParameterMetadata, error) {
+	path.Name = fqp(path.Name, ps.Cwd)
+	var nameRegexp *regexp.Regexp
+	if opts.Name != "" {
+		expr, err := globToRegexp(opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		nameRegexp, err = regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metadata, err := ps.describeParameters(path, true, findFilters(opts))
+	if err != nil {
+		return nil, err
+	}
+	var labeled map[string]bool
+	if opts.Label != "" {
+		labeled, err = ps.labeledParameters(path, opts.Label)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var r []ssm.ParameterMetadata
+	for _, m := range metadata {
+		if labeled != nil && !labeled[aws.StringValue(m.Name)] {
+			continue
+		}
+		if nameRegexp != nil && !nameRegexp.MatchString(spath.Base(aws.StringValue(m.Name))) {
+			continue
+		}
+		if !opts.ModifiedSince.IsZero() && aws.TimeValue(m.LastModifiedDate).Before(opts.ModifiedSince) {
+			continue
+		}
+		if opts.ModifiedBy != "" && !modifiedBy(aws.StringValue(m.LastModifiedUser), opts.ModifiedBy) {
+			continue
+		}
+		r = append(r, *m)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return aws.StringValue(r[i].Name) < aws.StringValue(r[j].Name)
+	})
+	return r, nil
+}
+
+// findFilters converts find criteria to DescribeParameters filters
+func findFilters(opts FindOptions) (filters []*ssm.ParameterStringFilter) {
+	equals := func(key, value string) {
+		if value != "" {
+			filters = append(filters, &ssm.ParameterStringFilter{
+				Key:    aws.String(key),
+				Option: aws.String("Equals"),
+				Values: aws.StringSlice([]string{value}),
+			})
+		}
+	}
+	equals("Type", opts.Type)
+	equals("KeyId", opts.KeyID)
+	equals("Tier", opts.Tier)
+	for _, t := range opts.Tags {
+		equals("tag:"+aws.StringValue(t.Key), aws.StringValue(t.Value))
+	}
+	return filters
+}
+
+// labeledParameters returns the names of the parameters beneath a path with a version that has a label
+func (ps *ParameterStore) labeledParameters(path ParameterPath, label string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	input := &ssm.GetParametersByPathInput{
+		Path:      aws.String(path.Name),
+		Recursive: aws.Bool(true),
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			{
+				Key:    aws.String("Label"),
+				Option: aws.String("Equals"),
+				Values: aws.StringSlice([]string{label}),
+			},
+		},
+	}
+	for {
+		resp, err := ps.client(path).GetParametersByPath(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Parameters {
+			names[aws.StringValue(p.Name)] = true
+		}
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	return names, nil
+}
+
+// modifiedBy reports whether a user ARN matches an ARN or a user name
+func modifiedBy(arn, user string) bool {
+	return arn == user || strings.HasSuffix(arn, Delimiter+user)
+}
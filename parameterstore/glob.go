@@ -0,0 +1,203 @@
+package parameterstore
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RegexPrefix marks a parameter name as a regular expression, e.g. re:/app/(dev|test)/.*
+const RegexPrefix = "re:"
+
+// globChars are the characters with special meaning in a glob
+const globChars = "*?{"
+
+// IsPattern reports whether a name is a glob or a regular expression rather than a literal name
+func IsPattern(name string) bool {
+	return strings.HasPrefix(name, RegexPrefix) || strings.ContainsAny(name, globChars)
+}
+
+// Expand returns the parameters whose names match a glob or regular expression, sorted by name.
+// Globs support * and ? within a level of the hierarchy, ** across levels, and {a,b} alternatives.
+// Regular expressions are prefixed with re: and must match the whole name. Relative patterns
+// are matched beneath the current directory.
+func (ps *ParameterStore) Expand(pattern ParameterPath) ([]ParameterPath, error) {
+	re, base, err := ps.compilePattern(pattern.Name)
+	if err != nil {
+		return nil, err
+	}
+	path := pattern
+	path.Name = base
+	params, err := ps.parametersByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var matches []ParameterPath
+	for _, p := range params {
+		if re.MatchString(p.Name) {
+			matches = append(matches, p)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Name < matches[j].Name
+	})
+	return matches, nil
+}
+
+// CopyPattern copies the parameters that match a pattern beneath a destination path,
+// keeping their names relative to the literal part of the pattern
+func (ps *ParameterStore) CopyPattern(src, dst ParameterPath, opts CopyOptions) error {
+	_, base, err := ps.compilePattern(src.Name)
+	if err != nil {
+		return err
+	}
+	matches, err := ps.Expand(src)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return notFound("No parameters match %s", src.Name)
+	}
+	dst.Name = fqp(dst.Name, ps.Cwd)
+	if ps.isParameter(dst) {
+		return fmt.Errorf("Cannot copy multiple parameters to parameter (%s)", dst.Name)
+	}
+	for _, m := range matches {
+		target := dst
+		target.Name = absoluteName(dst.Name, relativeName(m.Name, base))
+		err = ps.copyParameter(m, target, opts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compilePattern converts a glob or regular expression to an anchored regular expression
+// and finds the deepest path that contains every possible match
+func (ps *ParameterStore) compilePattern(pattern string) (*regexp.Regexp, string, error) {
+	if strings.HasPrefix(pattern, RegexPrefix) {
+		expr := strings.TrimPrefix(pattern, RegexPrefix)
+		if !strings.HasPrefix(expr, Delimiter) {
+			// Group the expression so that the prefix applies to every alternative
+			expr = regexp.QuoteMeta(strings.TrimSuffix(ps.Cwd, Delimiter)+Delimiter) + "(?:" + expr + ")"
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, "", err
+		}
+		if hasTopLevelAlternation(expr) {
+			// The alternatives may share no prefix, so search everything
+			return re, Delimiter, nil
+		}
+		return re, literalBase(regexpLiteral(expr)), nil
+	}
+
+	pattern = fqp(pattern, ps.Cwd)
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, "", err
+	}
+	literal := pattern
+	if i := strings.IndexAny(pattern, globChars); i >= 0 {
+		literal = pattern[:i]
+	}
+	return re, literalBase(literal), nil
+}
+
+// regexpLiteral returns the literal text at the start of a regular expression
+func regexpLiteral(expr string) string {
+	i := strings.IndexAny(expr, `\.+*?()|[]{}^$`)
+	if i < 0 {
+		return expr
+	}
+	if i > 0 && strings.ContainsAny(expr[i:i+1], "*?{") {
+		// The preceding character is optional or repeated
+		i--
+	}
+	return expr[:i]
+}
+
+// hasTopLevelAlternation reports whether a regular expression has a | outside any group or character class
+func hasTopLevelAlternation(expr string) bool {
+	depth := 0
+	inClass := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+			if strings.HasPrefix(expr[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(expr[i+1:], "]") {
+				// A ] at the start of a class is literal
+				i++
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '|' && depth == 0:
+			return true
+		}
+	}
+	return false
+}
+
+// literalBase returns the path containing a literal name prefix
+func literalBase(literal string) string {
+	i := strings.LastIndex(literal, Delimiter)
+	if i <= 0 {
+		return Delimiter
+	}
+	return literal[:i]
+}
+
+// globToRegexp translates a glob to a regular expression
+func globToRegexp(glob string) (string, error) {
+	var expr strings.Builder
+	depth := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**"+Delimiter):
+			// Zero or more levels of the hierarchy
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '{':
+			depth++
+			expr.WriteString("(?:")
+		case c == '}' && depth > 0:
+			depth--
+			expr.WriteString(")")
+		case c == ',' && depth > 0:
+			expr.WriteString("|")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if depth != 0 {
+		return "", errors.New("unmatched { in " + glob)
+	}
+	return expr.String(), nil
+}
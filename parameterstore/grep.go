@@ -0,0 +1,60 @@
+package parameterstore
+
+import (
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// Grep returns the parameters whose values match a regular expression. Paths are searched
+// one level deep, or recursively when recurse is true. SecureString values are only
+// searched when decryption is enabled.
+func (ps *ParameterStore) Grep(re *regexp.Regexp, params []ParameterPath, recurse bool) (r []ssm.Parameter, err error) {
+	for _, param := range params {
+		param.Name = fqp(param.Name, ps.Cwd)
+		var values []ssm.Parameter
+		if ps.isParameter(param) {
+			values, err = ps.Get([]string{param.Name}, ps.ClientKey(param))
+		} else if ps.isPath(param) {
+			values, err = ps.parameterValues(param, recurse)
+		} else {
+			err = notFound("No path or parameter %s was found", param.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if aws.StringValue(v.Type) == ssm.ParameterTypeSecureString && !ps.Decrypt {
+				continue
+			}
+			if re.MatchString(aws.StringValue(v.Value)) {
+				r = append(r, v)
+			}
+		}
+	}
+	return r, nil
+}
+
+// parameterValues returns the parameters beneath a path with their values
+func (ps *ParameterStore) parameterValues(path ParameterPath, recurse bool) (r []ssm.Parameter, err error) {
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(path.Name),
+		Recursive:      aws.Bool(recurse),
+		WithDecryption: aws.Bool(ps.Decrypt),
+	}
+	for {
+		resp, err := ps.client(path).GetParametersByPath(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Parameters {
+			r = append(r, *p)
+		}
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	return r, nil
+}
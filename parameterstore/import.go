@@ -0,0 +1,336 @@
+package parameterstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/mattn/go-shellwords"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportOptions controls how Import creates parameters
+type ImportOptions struct {
+	Type      string // Type of entries that do not specify one, defaults to ps.Type
+	Overwrite bool   // Update existing parameters whose value or metadata differ
+	DryRun    bool   // Report what would change without writing anything
+}
+
+// ImportResult lists the parameters affected by an import
+type ImportResult struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	Skipped   []string // Existing parameters that differ, not updated without ImportOptions.Overwrite
+}
+
+// parameterFieldNames are the keys of a parameter in structured import files
+var parameterFieldNames = map[string]bool{
+	"value":       true,
+	"type":        true,
+	"description": true,
+	"key":         true,
+	"tier":        true,
+	"policies":    true,
+	"tags":        true,
+}
+
+// Import creates or updates parameters beneath a prefix. Entry names are relative to the prefix.
+func (ps *ParameterStore) Import(params []ExportedParameter, prefix ParameterPath, opts ImportOptions) (result ImportResult, err error) {
+	if opts.Type == "" {
+		opts.Type = ps.Type
+	}
+	prefix.Name = fqp(prefix.Name, ps.Cwd)
+	existing, err := ps.describePath(prefix, true)
+	if err != nil {
+		return result, err
+	}
+	for _, p := range params {
+		input, err := ps.importInput(p, opts)
+		if err != nil {
+			return result, err
+		}
+		name := absoluteName(prefix.Name, strings.Trim(p.Name, Delimiter))
+		input.Name = aws.String(name)
+		current, exists := existing[relativeName(name, prefix.Name)]
+		switch {
+		case !exists:
+			result.Created = append(result.Created, name)
+		case !importChanged(input, current):
+			result.Unchanged = append(result.Unchanged, name)
+			continue
+		case !opts.Overwrite:
+			result.Skipped = append(result.Skipped, name)
+			continue
+		default:
+			input.Overwrite = aws.Bool(true)
+			result.Updated = append(result.Updated, name)
+		}
+		if opts.DryRun {
+			continue
+		}
+		_, err = ps.Put(input, ps.ClientKey(prefix))
+		if err != nil {
+			return result, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return result, nil
+}
+
+// importInput validates an imported parameter and converts it to a PutParameterInput
+func (ps *ParameterStore) importInput(p ExportedParameter, opts ImportOptions) (*ssm.PutParameterInput, error) {
+	paramType := p.Type
+	if paramType == "" {
+		paramType = opts.Type
+	}
+	input := &ssm.PutParameterInput{
+		Value:     aws.String(p.Value),
+		Overwrite: aws.Bool(false),
+	}
+	t, ok := matchValue(paramType, ssm.ParameterType_Values())
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid type %s", p.Name, paramType)
+	}
+	input.Type = aws.String(t)
+	if p.Description != "" {
+		input.Description = aws.String(p.Description)
+	}
+	if p.Key != "" {
+		input.KeyId = aws.String(p.Key)
+	} else if t == ssm.ParameterTypeSecureString && ps.Key != "" {
+		input.KeyId = aws.String(ps.Key)
+	}
+	if p.Tier != "" {
+		tier, ok := matchValue(p.Tier, ssm.ParameterTier_Values())
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid tier %s", p.Name, p.Tier)
+		}
+		input.Tier = aws.String(tier)
+	}
+	if p.Policies != "" {
+		input.Policies = aws.String(p.Policies)
+	}
+	var keys []string
+	for k := range p.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		input.Tags = append(input.Tags, &ssm.Tag{Key: aws.String(k), Value: aws.String(p.Tags[k])})
+	}
+	return input, nil
+}
+
+// importChanged reports whether putting an imported parameter would change the current parameter.
+// Metadata is only compared when the import specifies it.
+func importChanged(input *ssm.PutParameterInput, current ssm.ParameterHistory) bool {
+	if aws.StringValue(input.Value) != aws.StringValue(current.Value) ||
+		aws.StringValue(input.Type) != aws.StringValue(current.Type) {
+		return true
+	}
+	optional := []struct{ imported, current string }{
+		{aws.StringValue(input.Description), aws.StringValue(current.Description)},
+		{aws.StringValue(input.KeyId), aws.StringValue(current.KeyId)},
+		{aws.StringValue(input.Tier), aws.StringValue(current.Tier)},
+		{aws.StringValue(input.Policies), policyText(current.Policies)},
+	}
+	for _, f := range optional {
+		if f.imported != "" && f.imported != f.current {
+			return true
+		}
+	}
+	return false
+}
+
+// matchValue finds a value in a list of valid values, ignoring case
+func matchValue(s string, values []string) (string, bool) {
+	for _, v := range values {
+		if strings.EqualFold(s, v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// UnmarshalParameters decodes parameters from JSON, YAML or dotenv. In JSON and YAML, objects
+// with a value field are parameters and any other object is a level of the hierarchy.
+// Scalars and lists are parameters with only a value. dotenv variables keep their names.
+func UnmarshalParameters(data []byte, format string) ([]ExportedParameter, error) {
+	var tree interface{}
+	switch format {
+	case FormatJSON:
+		err := json.Unmarshal(data, &tree)
+		if err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		err := yaml.Unmarshal(data, &tree)
+		if err != nil {
+			return nil, err
+		}
+	case FormatDotenv:
+		return parseDotenv(data)
+	default:
+		return nil, fmt.Errorf("unknown format %s, expected one of %s", format, strings.Join([]string{FormatJSON, FormatYAML, FormatDotenv}, ", "))
+	}
+	root, ok := tree.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object at the top level of the %s file", format)
+	}
+	var params []ExportedParameter
+	err := flattenParameters(root, "", &params)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params, nil
+}
+
+// flattenParameters walks a decoded hierarchy, collecting parameters
+func flattenParameters(node map[string]interface{}, path string, params *[]ExportedParameter) error {
+	_, isParameter := node["value"]
+	if isParameter {
+		p, err := decodeParameter(node, path)
+		if err != nil {
+			return err
+		}
+		*params = append(*params, p)
+	}
+	for k, v := range node {
+		if isParameter && parameterFieldNames[k] {
+			// Metadata of the parameter decoded above
+			continue
+		}
+		name := strings.Trim(path+Delimiter+k, Delimiter)
+		if child, ok := v.(map[string]interface{}); ok {
+			err := flattenParameters(child, name, params)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := scalarValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		p := ExportedParameter{Name: name, Value: value}
+		if _, ok := v.([]interface{}); ok {
+			p.Type = ssm.ParameterTypeStringList
+		}
+		*params = append(*params, p)
+	}
+	return nil
+}
+
+// decodeParameter converts an object with a value field to a parameter
+func decodeParameter(node map[string]interface{}, name string) (p ExportedParameter, err error) {
+	p.Name = name
+	fields := map[string]*string{
+		"value":       &p.Value,
+		"type":        &p.Type,
+		"description": &p.Description,
+		"key":         &p.Key,
+		"tier":        &p.Tier,
+		"policies":    &p.Policies,
+	}
+	for k, dst := range fields {
+		v, ok := node[k]
+		if !ok {
+			continue
+		}
+		if *dst, err = scalarValue(v); err != nil {
+			return p, fmt.Errorf("%s: %s %v", name, k, err)
+		}
+	}
+	if _, ok := node["value"].([]interface{}); ok && p.Type == "" {
+		p.Type = ssm.ParameterTypeStringList
+	}
+	if tags, ok := node["tags"]; ok {
+		tagMap, ok := tags.(map[string]interface{})
+		if !ok {
+			return p, fmt.Errorf("%s: tags must be an object of keys and values", name)
+		}
+		p.Tags = make(map[string]string)
+		for k, v := range tagMap {
+			if p.Tags[k], err = scalarValue(v); err != nil {
+				return p, fmt.Errorf("%s: tag %s %v", name, k, err)
+			}
+		}
+	}
+	return p, nil
+}
+
+// scalarValue converts a decoded scalar to a string. Lists are joined with commas, as for StringList.
+func scalarValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case []interface{}:
+		var items []string
+		for _, item := range value {
+			s, err := scalarValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// parseDotenv reads KEY=value lines, ignoring blank lines, comments and any export prefix
+func parseDotenv(data []byte) (params []ExportedParameter, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value, err := dotenvValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		params = append(params, ExportedParameter{Name: strings.TrimSpace(parts[0]), Value: value})
+	}
+	return params, scanner.Err()
+}
+
+// dotenvValue removes the quoting from a dotenv value
+func dotenvValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if v, err := strconv.Unquote(s); err == nil {
+			return v, nil
+		}
+		return strings.Trim(s, `"`), nil
+	case strings.HasPrefix(s, "'"):
+		words, err := shellwords.Parse(s)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(words, " "), nil
+	}
+	return s, nil
+}
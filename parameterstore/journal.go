@@ -0,0 +1,228 @@
+package parameterstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// Journal actions
+const (
+	ActionPut    = "put"
+	ActionDelete = "delete"
+)
+
+// Change is a modification of a parameter along with the state it had beforehand
+type Change struct {
+	Action   string               // ActionPut or ActionDelete
+	Name     string               // The fully qualified name of the parameter
+	Region   string               // The region of the parameter
+	Profile  string               // The profile used to change the parameter
+	Unknown  bool                 // Whether the state before the change could not be read, so it cannot be undone
+	Existed  bool                 // Whether the parameter existed before the change
+	Previous ssm.ParameterHistory // The decrypted latest version before the change, when it existed
+	Tags     []ssm.Tag            // The tags before the change, of a deleted parameter or one that was tagged
+	Tagged   []string             // The keys of the tags added to an existing parameter
+}
+
+// Operation is a group of changes made by a single command
+type Operation struct {
+	Description string
+	Time        time.Time
+	Changes     []Change
+}
+
+// BeginOperation groups the changes that follow into an operation in the journal, until EndOperation is called
+func (ps *ParameterStore) BeginOperation(description string) {
+	ps.operation = &Operation{Description: description, Time: time.Now()}
+}
+
+// EndOperation adds the current operation to the journal if it made any changes
+func (ps *ParameterStore) EndOperation() {
+	if ps.operation != nil && len(ps.operation.Changes) > 0 {
+		ps.journal = append(ps.journal, *ps.operation)
+	}
+	ps.operation = nil
+}
+
+// Journal returns the operations made in this session, oldest first
+func (ps *ParameterStore) Journal() []Operation {
+	return append([]Operation(nil), ps.journal...)
+}
+
+// Undo reverts the last n operations in the journal, most recent first, restoring the
+// previous version of each changed parameter or deleting parameters that did not exist.
+// Returns the operations that were completely reverted. An operation that is partly
+// reverted when an error occurs remains in the journal with its outstanding changes.
+func (ps *ParameterStore) Undo(n int) (undone []Operation, err error) {
+	if n > len(ps.journal) {
+		return nil, fmt.Errorf("cannot undo %d operations, the journal has %d", n, len(ps.journal))
+	}
+	initialized := make(map[string]bool)
+	ps.undoing = true
+	defer func() {
+		ps.undoing = false
+	}()
+	for i := len(ps.journal) - n; i < len(ps.journal); i++ {
+		for _, c := range ps.journal[i].Changes {
+			if c.Unknown {
+				return nil, fmt.Errorf("cannot undo %s, the state of %s before it is unknown", ps.journal[i].Description, c.Name)
+			}
+		}
+	}
+	for ; n > 0; n-- {
+		last := len(ps.journal) - 1
+		op := ps.journal[last]
+		for i := len(op.Changes) - 1; i >= 0; i-- {
+			err = ps.revert(op.Changes[i], initialized)
+			if err != nil {
+				ps.journal[last].Changes = op.Changes[:i+1]
+				return undone, fmt.Errorf("undoing %s: %v", op.Description, err)
+			}
+		}
+		ps.journal = ps.journal[:last]
+		undone = append(undone, op)
+	}
+	return undone, nil
+}
+
+// revert restores the state of a parameter before a change. The client for the region and
+// profile of the change is created again once per undo, since switching region or profile
+// replaces the shell's clients.
+func (ps *ParameterStore) revert(c Change, initialized map[string]bool) error {
+	key := ps.ClientKey(ParameterPath{Region: c.Region, Profile: c.Profile})
+	if !initialized[key] {
+		err := ps.InitProfileClient(c.Region, c.Profile)
+		if err != nil {
+			return err
+		}
+		initialized[key] = true
+	}
+	if !c.Existed {
+		return ps.delete([]string{c.Name}, key)
+	}
+	input := putInputFromHistory(c.Previous, CopyOptions{})
+	input.Name = aws.String(c.Name)
+	if c.Action == ActionDelete {
+		for i := range c.Tags {
+			input.Tags = append(input.Tags, &c.Tags[i])
+		}
+		_, err := ps.Put(input, key)
+		return err
+	}
+	tier, err := ps.currentTier(ParameterPath{Name: c.Name, Region: c.Region, Profile: c.Profile})
+	if err != nil {
+		return err
+	}
+	input.Tier = tierFor(tier, input.Tier)
+	input.Overwrite = aws.Bool(true)
+	_, err = ps.Put(input, key)
+	if err != nil {
+		return err
+	}
+	return ps.untag(c, key)
+}
+
+// untag restores the tags of a parameter that were added or replaced by a change
+func (ps *ParameterStore) untag(c Change, key string) error {
+	if len(c.Tagged) == 0 {
+		return nil
+	}
+	previous := make(map[string]*ssm.Tag)
+	for i := range c.Tags {
+		previous[aws.StringValue(c.Tags[i].Key)] = &c.Tags[i]
+	}
+	var added []string
+	var restore []*ssm.Tag
+	for _, key := range c.Tagged {
+		if t, ok := previous[key]; ok {
+			restore = append(restore, t)
+		} else {
+			added = append(added, key)
+		}
+	}
+	if len(added) > 0 {
+		input := &ssm.RemoveTagsFromResourceInput{
+			ResourceId:   aws.String(c.Name),
+			ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+			TagKeys:      aws.StringSlice(added),
+		}
+		_, err := ps.clientFor(key).RemoveTagsFromResource(input)
+		if err != nil {
+			return err
+		}
+	}
+	if len(restore) > 0 {
+		return ps.addTags(c.Name, key, restore)
+	}
+	return nil
+}
+
+// journaling reports whether changes are recorded in the journal. Changes are not
+// recorded while undoing or in dry run mode, when nothing is changed.
+func (ps *ParameterStore) journaling() bool {
+	return !ps.undoing && !ps.DryRun
+}
+
+// priorState captures the state of a parameter before a change with the client for a key
+// from ClientKey
+func (ps *ParameterStore) priorState(action, name, key string) (Change, error) {
+	c := Change{Action: action, Name: name}
+	c.Profile, c.Region = ps.splitClientKey(key)
+	input := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+	for {
+		resp, err := ps.clientFor(key).GetParameterHistory(input)
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return c, nil
+		}
+		if err != nil {
+			return c, err
+		}
+		if len(resp.Parameters) > 0 {
+			c.Existed = true
+			c.Previous = *resp.Parameters[len(resp.Parameters)-1]
+		}
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	if c.Existed && action == ActionDelete {
+		tags, err := ps.listTags(name, key)
+		if err != nil {
+			return c, err
+		}
+		c.Tags = tags
+	}
+	return c, nil
+}
+
+// recordedState captures the state of a parameter before a change for the journal. A change
+// whose prior state cannot be read, e.g. without permission to decrypt it, is still made but
+// cannot be undone.
+func (ps *ParameterStore) recordedState(action, name, key string) Change {
+	c, err := ps.priorState(action, name, key)
+	if err != nil {
+		c = Change{Action: action, Name: name, Region: c.Region, Profile: c.Profile, Unknown: true}
+	}
+	return c
+}
+
+// record adds a change to the current operation, or to an operation of its own
+func (ps *ParameterStore) record(c Change) {
+	if ps.operation != nil {
+		ps.operation.Changes = append(ps.operation.Changes, c)
+		return
+	}
+	ps.journal = append(ps.journal, Operation{
+		Description: c.Action + " " + c.Name,
+		Time:        time.Now(),
+		Changes:     []Change{c},
+	})
+}
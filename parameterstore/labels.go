@@ -0,0 +1,121 @@
+package parameterstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// SelectorDelimiter separates a parameter name from a version or label selector, e.g. /app/url:3
+const SelectorDelimiter = ":"
+
+// LabelParameterVersion attaches labels to a version of a parameter. The version may be
+// selected with a name:version or name:label suffix, otherwise the latest version is labeled.
+func (ps *ParameterStore) LabelParameterVersion(param ParameterPath, labels []string) error {
+	name, version, err := ps.resolveVersion(param)
+	if err != nil {
+		return err
+	}
+	input := &ssm.LabelParameterVersionInput{
+		Name:   aws.String(name),
+		Labels: aws.StringSlice(labels),
+	}
+	if version != 0 {
+		input.ParameterVersion = aws.Int64(version)
+	}
+	resp, err := ps.client(param).LabelParameterVersion(input)
+	if err != nil {
+		return err
+	}
+	if len(resp.InvalidLabels) > 0 {
+		return fmt.Errorf("invalid labels %s", strings.Join(aws.StringValueSlice(resp.InvalidLabels), ","))
+	}
+	return nil
+}
+
+// UnlabelParameterVersion removes labels from a parameter. When no version is selected,
+// each label is removed from whichever version it is attached to.
+func (ps *ParameterStore) UnlabelParameterVersion(param ParameterPath, labels []string) error {
+	name, version, err := ps.resolveVersion(param)
+	if err != nil {
+		return err
+	}
+	labelsByVersion := make(map[int64][]string)
+	if version != 0 {
+		labelsByVersion[version] = labels
+	} else {
+		param.Name = name
+		history, err := ps.GetHistory(param)
+		if err != nil {
+			return err
+		}
+		for _, label := range labels {
+			h, ok := selectHistory(history, label)
+			if !ok {
+				return notFound("label %s is not attached to %s", label, name)
+			}
+			v := aws.Int64Value(h.Version)
+			labelsByVersion[v] = append(labelsByVersion[v], label)
+		}
+	}
+	for v, l := range labelsByVersion {
+		input := &ssm.UnlabelParameterVersionInput{
+			Name:             aws.String(name),
+			ParameterVersion: aws.Int64(v),
+			Labels:           aws.StringSlice(l),
+		}
+		resp, err := ps.client(param).UnlabelParameterVersion(input)
+		if err != nil {
+			return err
+		}
+		if len(resp.InvalidLabels) > 0 {
+			return notFound("labels %s are not attached to %s version %d",
+				strings.Join(aws.StringValueSlice(resp.InvalidLabels), ","), name, v)
+		}
+	}
+	return nil
+}
+
+// resolveVersion returns the name and selected version of a parameter.
+// The version is 0 when no selector is present.
+func (ps *ParameterStore) resolveVersion(param ParameterPath) (name string, version int64, err error) {
+	name, selector := splitSelector(fqp(param.Name, ps.Cwd))
+	if selector == "" {
+		return name, 0, nil
+	}
+	if version, err = strconv.ParseInt(selector, 10, 64); err == nil {
+		return name, version, nil
+	}
+	history, err := ps.GetHistory(param)
+	if err != nil {
+		return "", 0, err
+	}
+	return name, aws.Int64Value(history[0].Version), nil
+}
+
+// splitSelector separates a parameter name from its version or label selector, if any
+func splitSelector(name string) (string, string) {
+	i := strings.LastIndex(name, SelectorDelimiter)
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
+
+// selectHistory finds the history entry with a version number or label
+func selectHistory(history []ssm.ParameterHistory, selector string) (ssm.ParameterHistory, bool) {
+	for _, h := range history {
+		if strconv.FormatInt(aws.Int64Value(h.Version), 10) == selector {
+			return h, true
+		}
+		for _, label := range h.Labels {
+			if aws.StringValue(label) == selector {
+				return h, true
+			}
+		}
+	}
+	return ssm.ParameterHistory{}, false
+}
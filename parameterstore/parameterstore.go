@@ -3,13 +3,16 @@ package parameterstore
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	spath "path"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	saws "github.com/bwhaley/ssmsh/aws"
 	"github.com/bwhaley/ssmsh/config"
 )
@@ -18,24 +21,60 @@ import (
 const (
 	Delimiter            = "/"
 	DefaultParameterType = "SecureString"
+	ProfileDelimiter     = "@"
 )
 
+// ClientFactory creates an SSM client for a region and profile
+type ClientFactory func(region, profile string) ssmiface.SSMAPI
+
+// KMSClientFactory creates a KMS client for a region and profile
+type KMSClientFactory func(region, profile string) kmsiface.KMSAPI
+
+// STSClientFactory creates an STS client for a region and profile
+type STSClientFactory func(region, profile string) stsiface.STSAPI
+
 // ParameterStore represents the current state and preferences of the shell
 type ParameterStore struct {
-	Cwd       string                     // The current working directory in the hierarchy
-	Decrypt   bool                       // Decrypt values retrieved from Get
-	Type      string                     // Default parameter type (String, SecureString, StringList)
-	Key       string                     // The KMS key to use for SecureString parameters
-	Region    string                     // AWS region on which to operate
-	Overwrite bool                       // Whether or not to overwrite parameters
-	Profile   string                     // Profile to use from .aws/[config|credentials]
-	Clients   map[string]ssmiface.SSMAPI // per-region SSM clients
+	Cwd          string                     // The current working directory in the hierarchy
+	Decrypt      bool                       // Decrypt values retrieved from Get
+	Type         string                     // Default parameter type (String, SecureString, StringList)
+	Key          string                     // The KMS key to use for SecureString parameters
+	Region       string                     // AWS region on which to operate
+	Overwrite    bool                       // Whether or not to overwrite parameters
+	Profile      string                     // Profile to use from .aws/[config|credentials]
+	Clients      map[string]ssmiface.SSMAPI // per-region SSM clients, keyed by profile@region for other profiles
+	NewClient    ClientFactory              // Creates SSM clients. Defaults to clients of the AWS API
+	KMSClients   map[string]kmsiface.KMSAPI // KMS clients for backups, keyed like Clients and created on demand
+	NewKMSClient KMSClientFactory           // Creates KMS clients. Defaults to clients of the AWS API
+	DryRun       bool                       // Record calls that would modify parameters instead of making them
+	ReadOnly     bool                       // Refuse calls that would modify parameters
+	Protected    []string                   // Path prefixes beneath which parameters cannot be changed
+	AuditLog     io.Writer                  // Where to write an audit entry for each call that modifies parameters, if set
+	STSClients   map[string]stsiface.STSAPI // STS clients for the audit log, keyed like Clients and created on demand
+	NewSTSClient STSClientFactory           // Creates STS clients. Defaults to clients of the AWS API
+	Session      saws.Options               // How sessions reach AWS: endpoint, FIPS, dual-stack, CA bundle and retries
+	identities   map[string]string          // Caller ARNs for the audit log, keyed like Clients
+	plan         []PlannedCall              // Calls recorded in dry run mode
+	journal      []Operation                // Changes made in this session, for undo
+	operation    *Operation                 // The operation in progress, if any
+	undoing      bool                       // Whether changes are being reverted
 }
 
 // SetConfig sets the shels configuration state
 func (ps *ParameterStore) SetDefaults(cfg config.Config) {
 	ps.Decrypt = cfg.Default.Decrypt
 	ps.Overwrite = cfg.Default.Overwrite
+	ps.ReadOnly = cfg.Default.ReadOnly
+	ps.Protected = cfg.Protect.Refuse
+	ps.Session = saws.Options{
+		Endpoint:    cfg.Default.Endpoint,
+		KMSEndpoint: cfg.Default.KMSEndpoint,
+		STSEndpoint: cfg.Default.STSEndpoint,
+		FIPS:        cfg.Default.FIPS,
+		DualStack:   cfg.Default.DualStack,
+		CABundle:    cfg.Default.CABundle,
+		MaxRetries:  cfg.Default.MaxRetries,
+	}
 
 	// The value in the $AWS_PROFILE env var is most preferred
 	ps.Profile = os.Getenv("AWS_PROFILE")
@@ -73,7 +112,10 @@ func (ps *ParameterStore) NewParameterStore(checkCredentials bool) error {
 	ps.Cwd = Delimiter
 
 	ps.Clients = make(map[string]ssmiface.SSMAPI)
-	ps.Clients[ps.Region] = ssm.New(saws.NewSession(ps.Region, ps.Profile))
+	err := ps.InitClient(ps.Region)
+	if err != nil {
+		return err
+	}
 
 	if checkCredentials {
 		// Check for a non-existent parameter to validate credentials & permissions
@@ -86,14 +128,67 @@ func (ps *ParameterStore) NewParameterStore(checkCredentials bool) error {
 }
 
 // InitClient initializes an SSM client in a given region
-func (ps *ParameterStore) InitClient(region string) {
-	ps.Clients[region] = ssm.New(saws.NewSession(region, ps.Profile))
+func (ps *ParameterStore) InitClient(region string) error {
+	client, err := ps.newClient(region, ps.Profile)
+	if err != nil {
+		return err
+	}
+	ps.Clients[region] = client
+	return nil
+}
+
+// SetProfile switches the shell to another profile. The caller identities and the KMS and
+// STS clients cached for the previous profile are discarded. The profile is unchanged if a
+// client cannot be created with the new one.
+func (ps *ParameterStore) SetProfile(profile string) error {
+	client, err := ps.newClient(ps.Region, profile)
+	if err != nil {
+		return err
+	}
+	ps.Profile = profile
+	ps.identities = nil
+	ps.KMSClients = nil
+	ps.STSClients = nil
+	ps.Clients[ps.Region] = client
+	return nil
+}
+
+// InitProfileClient initializes an SSM client in a given region using a profile other than the shell's
+func (ps *ParameterStore) InitProfileClient(region, profile string) error {
+	client, err := ps.newClient(region, profile)
+	if err != nil {
+		return err
+	}
+	ps.Clients[ps.ClientKey(ParameterPath{Region: region, Profile: profile})] = client
+	return nil
+}
+
+// newClient creates an SSM client for a region and profile
+func (ps *ParameterStore) newClient(region, profile string) (ssmiface.SSMAPI, error) {
+	if ps.NewClient != nil {
+		return ps.NewClient(region, profile), nil
+	}
+	return saws.NewSSM(region, profile, ps.Session)
 }
 
 // ParameterPath abstracts a parameter to include some metadata
 type ParameterPath struct {
-	Name   string
-	Region string
+	Name    string
+	Region  string
+	Profile string // Set when the parameter is accessed with a profile other than the shell's
+}
+
+// ClientKey returns the key of the client for a parameter's region and profile
+func (ps *ParameterStore) ClientKey(path ParameterPath) string {
+	if path.Profile == "" || path.Profile == ps.Profile {
+		return path.Region
+	}
+	return path.Profile + ProfileDelimiter + path.Region
+}
+
+// client returns the SSM client for a parameter's region and profile
+func (ps *ParameterStore) client(path ParameterPath) ssmiface.SSMAPI {
+	return ps.clientFor(ps.ClientKey(path))
 }
 
 // SetCwd sets the current working dir within the parameter store
@@ -106,7 +201,7 @@ func (ps *ParameterStore) SetCwd(path ParameterPath) error {
 	if ps.isPath(path) {
 		ps.Cwd = path.Name
 	} else {
-		return errors.New("No such path")
+		return notFound("No such path")
 	}
 	return nil
 }
@@ -123,7 +218,6 @@ func (ps *ParameterStore) List(ppath ParameterPath, recurse bool, lr chan ListRe
 	results := []string{}
 
 	path := ppath.Name
-	region := ppath.Region
 	// Check for parameters under this path
 	path = fqp(path, ps.Cwd)
 
@@ -143,9 +237,10 @@ func (ps *ParameterStore) List(ppath ParameterPath, recurse bool, lr chan ListRe
 			return
 		default:
 		}
-		resp, err := ps.Clients[region].GetParametersByPath(params)
+		resp, err := ps.client(ppath).GetParametersByPath(params)
 		if err != nil {
 			lr <- ListResult{nil, err}
+			return
 		}
 		for _, p := range resp.Parameters {
 			results = append(results, aws.StringValue(p.Name))
@@ -160,7 +255,7 @@ func (ps *ParameterStore) List(ppath ParameterPath, recurse bool, lr chan ListRe
 	}
 
 	// Check if this path is a parameter (could be both path & parameter)
-	param, err := ps.Get([]string{path}, region)
+	param, err := ps.Get([]string{path}, ps.ClientKey(ppath))
 	if err != nil {
 		lr <- ListResult{nil, err}
 		return
@@ -175,43 +270,69 @@ func (ps *ParameterStore) List(ppath ParameterPath, recurse bool, lr chan ListRe
 
 // Remove removes one or more parameters
 func (ps *ParameterStore) Remove(params []ParameterPath, recurse bool) (err error) {
-	var parametersToDelete []ParameterPath
+	parametersToDelete, err := ps.resolveParameters(params, recurse)
+	if err != nil {
+		return err
+	}
+	return ps.deleteByClient(parametersToDelete)
+}
+
+// Resolve returns the parameters named by a list of parameters and paths. Paths are
+// expanded to all of the parameters beneath them when recurse is true.
+func (ps *ParameterStore) Resolve(params []ParameterPath, recurse bool) ([]ParameterPath, error) {
+	return ps.resolveParameters(params, recurse)
+}
+
+// Delete removes parameters that have already been resolved, e.g. by Resolve
+func (ps *ParameterStore) Delete(params []ParameterPath) error {
+	return ps.deleteByClient(params)
+}
+
+// Exists reports whether a parameter or path exists
+func (ps *ParameterStore) Exists(param ParameterPath) bool {
+	param.Name = fqp(param.Name, ps.Cwd)
+	return ps.isParameter(param) || ps.isPath(param)
+}
+
+// resolveParameters returns the parameters named by a list of parameters and paths.
+// Paths are expanded to all of the parameters beneath them when recurse is true.
+func (ps *ParameterStore) resolveParameters(params []ParameterPath, recurse bool) (resolved []ParameterPath, err error) {
 	for _, param := range params {
 		param.Name = fqp(param.Name, ps.Cwd)
 		if ps.isParameter(param) {
-			parametersToDelete = append(parametersToDelete, param)
+			resolved = append(resolved, param)
 		} else if ps.isPath(param) {
-			if recurse {
-				err = ps.recursiveDelete(param)
-				if err != nil {
-					return err
-				}
-			} else {
-				return fmt.Errorf("tried to delete path %s but recursive not requested", param.Name)
+			if !recurse {
+				return nil, fmt.Errorf("%s is a path but recursion was not requested", param.Name)
 			}
+			pathParams, err := ps.parametersByPath(param)
+			if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, pathParams...)
 		} else {
-			return fmt.Errorf("No path or parameter %s was found, aborting", param.Name)
+			return nil, notFound("No path or parameter %s was found, aborting", param.Name)
 		}
 	}
-	return ps.deleteByRegion(parametersToDelete)
+	return resolved, nil
 }
 
-// recursiveDelete deletes all the parameters under a given path
-func (ps *ParameterStore) recursiveDelete(path ParameterPath) (err error) {
-	var parametersToDelete []ParameterPath
+// parametersByPath returns all the parameters under a given path
+func (ps *ParameterStore) parametersByPath(path ParameterPath) (params []ParameterPath, err error) {
 	additionalParams := &ssm.GetParametersByPathInput{
 		Path:      aws.String(path.Name),
 		Recursive: aws.Bool(true),
 	}
 	for {
-		resp, err := ps.Clients[path.Region].GetParametersByPath(additionalParams)
+		resp, err := ps.client(path).GetParametersByPath(additionalParams)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for _, r := range resp.Parameters {
-			parametersToDelete = append(parametersToDelete, ParameterPath{
-				Name:   aws.StringValue(r.Name),
-				Region: path.Region,
+			params = append(params, ParameterPath{
+				Name:    aws.StringValue(r.Name),
+				Region:  path.Region,
+				Profile: path.Profile,
 			})
 		}
 		if aws.StringValue(resp.NextToken) == "" {
@@ -219,18 +340,18 @@ func (ps *ParameterStore) recursiveDelete(path ParameterPath) (err error) {
 		}
 		additionalParams.NextToken = resp.NextToken
 	}
-	return ps.deleteByRegion(parametersToDelete)
-
+	return params, nil
 }
 
-// deleteByRegion groups parameters by region before calling delete()
-func (ps *ParameterStore) deleteByRegion(params []ParameterPath) (err error) {
-	paramsByRegion := make(map[string][]string)
+// deleteByClient groups parameters by client (region and profile) before calling delete()
+func (ps *ParameterStore) deleteByClient(params []ParameterPath) (err error) {
+	paramsByClient := make(map[string][]string)
 	for _, p := range params {
-		paramsByRegion[p.Region] = append(paramsByRegion[p.Region], p.Name)
+		key := ps.ClientKey(p)
+		paramsByClient[key] = append(paramsByClient[key], p.Name)
 	}
-	for region, params := range paramsByRegion {
-		err := ps.delete(params, region)
+	for key, params := range paramsByClient {
+		err := ps.delete(params, key)
 		if err != nil {
 			return err
 		}
@@ -238,7 +359,8 @@ func (ps *ParameterStore) deleteByRegion(params []ParameterPath) (err error) {
 	return nil
 }
 
-func (ps *ParameterStore) delete(params []string, region string) (err error) {
+// delete deletes parameters with the client for a key from ClientKey
+func (ps *ParameterStore) delete(params []string, key string) (err error) {
 	const maxParams = 10
 	var invalidParams []string
 	var arrayEnd int
@@ -253,10 +375,25 @@ func (ps *ParameterStore) delete(params []string, region string) (err error) {
 		ssmParams := &ssm.DeleteParametersInput{
 			Names: ps.inputPaths(deleteBatch),
 		}
-		resp, err := ps.Clients[region].DeleteParameters(ssmParams)
+		var changes []Change
+		if ps.journaling() {
+			for _, name := range deleteBatch {
+				changes = append(changes, ps.recordedState(ActionDelete, name, key))
+			}
+		}
+		resp, err := ps.clientFor(key).DeleteParameters(ssmParams)
 		if err != nil {
 			return err
 		}
+		deleted := make(map[string]bool)
+		for _, d := range resp.DeletedParameters {
+			deleted[aws.StringValue(d)] = true
+		}
+		for _, c := range changes {
+			if deleted[c.Name] {
+				ps.record(c)
+			}
+		}
 		for _, r := range resp.InvalidParameters {
 			invalidParams = append(invalidParams, aws.StringValue(r))
 		}
@@ -267,14 +404,21 @@ func (ps *ParameterStore) delete(params []string, region string) (err error) {
 	return nil
 }
 
-// GetHistory returns the parameter history
-func (ps *ParameterStore) GetHistory(param ParameterPath) (r []ssm.ParameterHistory, err error) {
+// GetHistory returns the parameter history. If the name includes a version
+// or label selector (name:3, name:label), only the selected version is returned.
+func (ps *ParameterStore) GetHistory(param ParameterPath) ([]ssm.ParameterHistory, error) {
+	return ps.history(param, ps.Decrypt)
+}
+
+// history returns the parameter history like GetHistory, decrypting values if requested
+func (ps *ParameterStore) history(param ParameterPath, decrypt bool) (r []ssm.ParameterHistory, err error) {
+	name, selector := splitSelector(fqp(param.Name, ps.Cwd))
 	history := &ssm.GetParameterHistoryInput{
-		Name:           aws.String(fqp(param.Name, ps.Cwd)),
-		WithDecryption: aws.Bool(ps.Decrypt),
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(decrypt),
 	}
 	for {
-		resp, err := ps.Clients[param.Region].GetParameterHistory(history)
+		resp, err := ps.client(param).GetParameterHistory(history)
 		if err != nil {
 			return nil, err
 		}
@@ -286,16 +430,23 @@ func (ps *ParameterStore) GetHistory(param ParameterPath) (r []ssm.ParameterHist
 		}
 		history.NextToken = resp.NextToken
 	}
+	if selector != "" {
+		selected, ok := selectHistory(r, selector)
+		if !ok {
+			return nil, notFound("no version of %s matches %s", name, selector)
+		}
+		r = []ssm.ParameterHistory{selected}
+	}
 	return r, nil
 }
 
-// Get retrieves one or more parameters
-func (ps *ParameterStore) Get(params []string, region string) (r []ssm.Parameter, err error) {
+// Get retrieves one or more parameters with the client for a key from ClientKey
+func (ps *ParameterStore) Get(params []string, key string) (r []ssm.Parameter, err error) {
 	ssmParams := &ssm.GetParametersInput{
 		Names:          ps.inputPaths(params),
 		WithDecryption: aws.Bool(ps.Decrypt),
 	}
-	resp, err := ps.Clients[region].GetParameters(ssmParams)
+	resp, err := ps.clientFor(key).GetParameters(ssmParams)
 	if err != nil {
 		return nil, err
 	}
@@ -305,19 +456,55 @@ func (ps *ParameterStore) Get(params []string, region string) (r []ssm.Parameter
 	return r, nil
 }
 
-// Put creates or updates a parameter
-func (ps *ParameterStore) Put(param *ssm.PutParameterInput, region string) (resp *ssm.PutParameterOutput, err error) {
-	resp, err = ps.Clients[region].PutParameter(param)
+// Put creates or updates a parameter with the client for a key from ClientKey
+func (ps *ParameterStore) Put(param *ssm.PutParameterInput, key string) (resp *ssm.PutParameterOutput, err error) {
+	var tags []*ssm.Tag
+	if aws.BoolValue(param.Overwrite) && len(param.Tags) > 0 {
+		// PutParameter rejects tags when overwriting, so they are added separately
+		untagged := *param
+		untagged.Tags = nil
+		tags, param = param.Tags, &untagged
+	}
+	var change Change
+	if ps.journaling() {
+		change = ps.recordedState(ActionPut, aws.StringValue(param.Name), key)
+		if change.Existed && len(tags) > 0 {
+			change.Tags, err = ps.listTags(aws.StringValue(param.Name), key)
+			if err != nil {
+				change.Unknown = true
+			}
+			for _, t := range tags {
+				change.Tagged = append(change.Tagged, aws.StringValue(t.Key))
+			}
+		}
+	}
+	resp, err = ps.clientFor(key).PutParameter(param)
 	if err != nil {
 		return resp, err
 	}
+	if ps.journaling() {
+		ps.record(change)
+	}
+	if len(tags) > 0 {
+		err = ps.addTags(aws.StringValue(param.Name), key, tags)
+		if err != nil {
+			return resp, err
+		}
+	}
 	return resp, nil
 }
 
+// CopyOptions controls which attributes of a parameter are not carried over by Copy and Move
+type CopyOptions struct {
+	SkipTags     bool // Do not copy resource tags
+	SkipTier     bool // Do not copy the tier. Policies require the advanced tier, so this implies SkipPolicies
+	SkipPolicies bool // Do not copy parameter policies
+}
+
 // Move moves a parameter or path to another location
-func (ps *ParameterStore) Move(src, dst ParameterPath) error {
+func (ps *ParameterStore) Move(src, dst ParameterPath, opts CopyOptions) error {
 	var err error
-	err = ps.Copy(src, dst, true)
+	err = ps.Copy(src, dst, true, opts)
 	if err != nil {
 		return err
 	}
@@ -329,17 +516,9 @@ func (ps *ParameterStore) Move(src, dst ParameterPath) error {
 }
 
 // Copy duplicates a parameter from src to dst
-func (ps *ParameterStore) Copy(src, dst ParameterPath, recurse bool) error {
+func (ps *ParameterStore) Copy(src, dst ParameterPath, recurse bool, opts CopyOptions) error {
 	var srcIsParameter, dstIsParameter, srcIsPath, dstIsPath bool
 
-	if !ps.Decrypt {
-		// Decryption required for copy
-		ps.Decrypt = true
-		defer func() {
-			ps.Decrypt = false
-		}()
-	}
-
 	src.Name = fqp(src.Name, ps.Cwd)
 	dst.Name = fqp(dst.Name, ps.Cwd)
 
@@ -354,9 +533,9 @@ func (ps *ParameterStore) Copy(src, dst ParameterPath, recurse bool) error {
 	}
 
 	if srcIsParameter && !dstIsPath {
-		return ps.copyParameter(src, dst)
+		return ps.copyParameter(src, dst, opts)
 	} else if srcIsParameter && dstIsPath {
-		return ps.copyParameterToPath(src, dst)
+		return ps.copyParameterToPath(src, dst, opts)
 	} else if srcIsPath && dstIsParameter {
 		return fmt.Errorf("Cannot copy path (%s) to parameter (%s)", src, dst)
 	} else if srcIsPath {
@@ -364,36 +543,52 @@ func (ps *ParameterStore) Copy(src, dst ParameterPath, recurse bool) error {
 			return fmt.Errorf("%s and %s are both paths but recursion not requested. Use -R", src, dst)
 		}
 		if dstIsPath {
-			return ps.copyPathToPath(false, src, dst)
+			return ps.copyPathToPath(false, src, dst, opts)
 		}
-		return ps.copyPathToPath(true, src, dst)
+		return ps.copyPathToPath(true, src, dst, opts)
 	}
-	return fmt.Errorf("%s is not a path or parameter", src.Name)
+	return notFound("%s is not a path or parameter", src.Name)
 }
 
-// copyParameter copies one parameter to a new name
-func (ps *ParameterStore) copyParameter(src, dst ParameterPath) error {
+// copyParameter copies one parameter to a new name. The KMS key is left out when dst uses
+// another region or profile, and the tier as decided by tierFor.
+func (ps *ParameterStore) copyParameter(src, dst ParameterPath, opts CopyOptions) error {
 	if !ps.isParameter(src) {
 		return errors.New("source must be a parameter: " + src.Name)
 	}
-	pHist, err := ps.GetHistory(src)
+	pHist, err := ps.history(src, true)
 	if err != nil {
 		return err
 	}
 	pLatest := pHist[len(pHist)-1]
+	src.Name, _ = splitSelector(src.Name)
 	if dst.Name == Delimiter {
 		dst.Name = src.Name
 	}
-	putParamInput := &ssm.PutParameterInput{
-		Name:           aws.String(dst.Name),
-		Type:           pLatest.Type,
-		Value:          pLatest.Value,
-		KeyId:          pLatest.KeyId,
-		Description:    pLatest.Description,
-		AllowedPattern: pLatest.AllowedPattern,
-		Overwrite:      aws.Bool(ps.Overwrite),
-	}
-	_, err = ps.Put(putParamInput, dst.Region)
+	putParamInput := putInputFromHistory(pLatest, opts)
+	putParamInput.Name = aws.String(dst.Name)
+	putParamInput.Overwrite = aws.Bool(ps.Overwrite)
+	if ps.ClientKey(src) != ps.ClientKey(dst) {
+		// KMS keys belong to a region and account, so the default key of the destination is used
+		putParamInput.KeyId = nil
+	}
+	if putParamInput.Tier != nil && ps.Overwrite {
+		tier, err := ps.currentTier(dst)
+		if err != nil {
+			return err
+		}
+		putParamInput.Tier = tierFor(tier, putParamInput.Tier)
+	}
+	if !opts.SkipTags {
+		tags, err := ps.listTags(src.Name, ps.ClientKey(src))
+		if err != nil {
+			return err
+		}
+		for i := range tags {
+			putParamInput.Tags = append(putParamInput.Tags, &tags[i])
+		}
+	}
+	_, err = ps.Put(putParamInput, ps.ClientKey(dst))
 	if err != nil {
 		return err
 	}
@@ -401,14 +596,15 @@ func (ps *ParameterStore) copyParameter(src, dst ParameterPath) error {
 }
 
 // copyParameterToPath copies a parameter to a given path (preserving the parameter name)
-func (ps *ParameterStore) copyParameterToPath(srcParam, dstPath ParameterPath) error {
-	srcParamElements := strings.Split(srcParam.Name, Delimiter)
+func (ps *ParameterStore) copyParameterToPath(srcParam, dstPath ParameterPath, opts CopyOptions) error {
+	srcName, _ := splitSelector(srcParam.Name)
+	srcParamElements := strings.Split(srcName, Delimiter)
 	dstPath.Name = dstPath.Name + Delimiter + srcParamElements[len(srcParamElements)-1]
-	return ps.copyParameter(srcParam, dstPath)
+	return ps.copyParameter(srcParam, dstPath, opts)
 }
 
 // copyPathToPath copies the parameters at a source path to a new destination path
-func (ps *ParameterStore) copyPathToPath(newPath bool, srcPath, dstPath ParameterPath) error {
+func (ps *ParameterStore) copyPathToPath(newPath bool, srcPath, dstPath ParameterPath, opts CopyOptions) error {
 	/*
 		1) Get all source parameters
 		2) Map sources to destinations
@@ -419,13 +615,13 @@ func (ps *ParameterStore) copyPathToPath(newPath bool, srcPath, dstPath Paramete
 		Recursive: aws.Bool(true),
 	}
 	for {
-		resp, err := ps.Clients[srcPath.Region].GetParametersByPath(params)
+		resp, err := ps.client(srcPath).GetParametersByPath(params)
 		if err != nil {
 			return err
 		}
 		paramMap := makeParameterMap(resp.Parameters, newPath, srcPath, dstPath)
 		for src, dst := range paramMap {
-			err = ps.copyParameter(src, dst)
+			err = ps.copyParameter(src, dst, opts)
 			if err != nil {
 				return err
 			}
@@ -438,13 +634,52 @@ func (ps *ParameterStore) copyPathToPath(newPath bool, srcPath, dstPath Paramete
 	return nil
 }
 
+// putInputFromHistory creates the input to recreate a version of a parameter, without the name
+func putInputFromHistory(h ssm.ParameterHistory, opts CopyOptions) *ssm.PutParameterInput {
+	putParamInput := &ssm.PutParameterInput{
+		Type:           h.Type,
+		Value:          h.Value,
+		KeyId:          h.KeyId,
+		Description:    h.Description,
+		AllowedPattern: h.AllowedPattern,
+		DataType:       h.DataType,
+	}
+	if !opts.SkipTier {
+		putParamInput.Tier = h.Tier
+		if !opts.SkipPolicies && len(h.Policies) > 0 {
+			putParamInput.Policies = aws.String(policiesJSON(h.Policies))
+		}
+	}
+	return putParamInput
+}
+
+// tierFor returns the tier to put over a parameter whose current tier is dstTier, given the
+// tier to be copied from another parameter or version. The tier is left out when the parameter
+// is advanced, because parameters cannot be downgraded to the standard tier.
+func tierFor(dstTier string, srcTier *string) *string {
+	if dstTier == ssm.ParameterTierAdvanced {
+		return nil
+	}
+	return srcTier
+}
+
+// policiesJSON converts the policies of an existing parameter to the format expected by PutParameter
+func policiesJSON(policies []*ssm.ParameterInlinePolicy) string {
+	var texts []string
+	for _, p := range policies {
+		texts = append(texts, aws.StringValue(p.PolicyText))
+	}
+	return "[" + strings.Join(texts, ",") + "]"
+}
+
 // makeParameterMap returns a map of source param name to dest param name
 func makeParameterMap(params []*ssm.Parameter, newPath bool, srcPath, dstPath ParameterPath) (sourceToDst map[ParameterPath]ParameterPath) {
 	sourceToDst = make(map[ParameterPath]ParameterPath)
 	for _, p := range params {
 		srcParam := ParameterPath{
-			Name:   aws.StringValue(p.Name),
-			Region: srcPath.Region,
+			Name:    aws.StringValue(p.Name),
+			Region:  srcPath.Region,
+			Profile: srcPath.Profile,
 		}
 		srcPathElements := strings.Split(srcPath.Name, Delimiter)
 		srcBasePath := srcPathElements[len(srcPathElements)-1]
@@ -466,8 +701,9 @@ func makeParameterMap(params []*ssm.Parameter, newPath bool, srcPath, dstPath Pa
 		}
 
 		dstParam := ParameterPath{
-			Name:   name,
-			Region: dstPath.Region,
+			Name:    name,
+			Region:  dstPath.Region,
+			Profile: dstPath.Profile,
 		}
 		sourceToDst[srcParam] = dstParam
 	}
@@ -487,7 +723,6 @@ func (ps *ParameterStore) inputPaths(paths []string) []*string {
 
 // fqp (fully qualified path) cleans a provided path
 // relative paths are prefixed with cwd
-// TODO Support regex or globbing
 func fqp(path string, cwd string) string {
 	var dirtyPath string
 	if strings.HasPrefix(path, Delimiter) {
@@ -505,10 +740,19 @@ func (ps *ParameterStore) isParameter(param ParameterPath) bool {
 	p := &ssm.GetParameterInput{
 		Name: aws.String(param.Name),
 	}
-	_, err := ps.Clients[param.Region].GetParameter(p)
+	_, err := ps.client(param).GetParameter(p)
 	return err == nil
 }
 
+// currentTier returns the tier of a parameter, or an empty string if it does not exist
+func (ps *ParameterStore) currentTier(param ParameterPath) (string, error) {
+	metadata, err := ps.Describe([]ParameterPath{param})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(metadata[fqp(param.Name, ps.Cwd)].Tier), nil
+}
+
 // isPath checks for the existence of at least one key under path
 func (ps *ParameterStore) isPath(path ParameterPath) bool {
 	var err error
@@ -516,7 +760,7 @@ func (ps *ParameterStore) isPath(path ParameterPath) bool {
 		Path:      aws.String(path.Name),
 		Recursive: aws.Bool(true),
 	}
-	resp, err := ps.Clients[path.Region].GetParametersByPath(params)
+	resp, err := ps.client(path).GetParametersByPath(params)
 	if err != nil {
 		return false
 	}
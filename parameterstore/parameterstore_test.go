@@ -1,14 +1,26 @@
 package parameterstore_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/bwhaley/ssmsh/parameterstore"
+	"github.com/bwhaley/ssmsh/parameterstore/fake"
 )
 
 var EddardStark = &ssm.Parameter{
@@ -62,16 +74,69 @@ type mockedSSM struct {
 	GetParameterResp        []ssm.GetParameterOutput
 	DeleteParametersResp    ssm.DeleteParametersOutput
 	PutParameterResp        ssm.PutParameterOutput
+	ListTagsForResourceResp ssm.ListTagsForResourceOutput
+	// Responses keyed by path, used instead of the responses above when present
+	GetParametersByPathResps map[string]ssm.GetParametersByPathOutput
+	DescribeParametersResps  map[string]ssm.DescribeParametersOutput
+	// Responses keyed by name, used instead of the responses above when present
+	GetParameterHistoryResps map[string]ssm.GetParameterHistoryOutput
+	GetParameterHistoryErr   error
+	Calls                    *mockCalls
+}
+
+// mockCalls records the inputs of mutating calls made to a mockedSSM
+type mockCalls struct {
+	PutParameter            []ssm.PutParameterInput
+	DeleteParameters        []ssm.DeleteParametersInput
+	DescribeParameters      []ssm.DescribeParametersInput
+	AddTagsToResource       []ssm.AddTagsToResourceInput
+	RemoveTagsFromResource  []ssm.RemoveTagsFromResourceInput
+	LabelParameterVersion   []ssm.LabelParameterVersionInput
+	UnlabelParameterVersion []ssm.UnlabelParameterVersionInput
 }
 
 func (m mockedSSM) GetParametersByPath(in *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	if resp, ok := m.GetParametersByPathResps[aws.StringValue(in.Path)]; ok {
+		return &resp, nil
+	}
 	if aws.StringValue(in.NextToken) != "" {
 		return &m.GetParametersByPathNext, nil
 	}
 	return &m.GetParametersByPathResp, nil
 }
 
+func (m mockedSSM) DescribeParameters(in *ssm.DescribeParametersInput) (*ssm.DescribeParametersOutput, error) {
+	if m.Calls != nil {
+		m.Calls.DescribeParameters = append(m.Calls.DescribeParameters, *in)
+	}
+	for _, f := range in.ParameterFilters {
+		switch aws.StringValue(f.Key) {
+		case "Path":
+			if resp, ok := m.DescribeParametersResps[aws.StringValue(f.Values[0])]; ok {
+				return &resp, nil
+			}
+		case "Name":
+			// Names are found among the responses for every path
+			var out ssm.DescribeParametersOutput
+			for _, resp := range m.DescribeParametersResps {
+				for _, p := range resp.Parameters {
+					for _, v := range f.Values {
+						if aws.StringValue(p.Name) == aws.StringValue(v) {
+							out.Parameters = append(out.Parameters, p)
+						}
+					}
+				}
+			}
+			return &out, nil
+		}
+	}
+	return &ssm.DescribeParametersOutput{}, nil
+}
+
 func (m mockedSSM) DeleteParameters(in *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	if m.Calls != nil {
+		m.Calls.DeleteParameters = append(m.Calls.DeleteParameters, *in)
+	}
 	return &m.DeleteParametersResp, nil
 }
 
@@ -86,6 +151,13 @@ func (m mockedSSM) GetParameter(in *ssm.GetParameterInput) (*ssm.GetParameterOut
 }
 
 func (m mockedSSM) GetParameterHistory(in *ssm.GetParameterHistoryInput) (*ssm.GetParameterHistoryOutput, error) {
+	if m.GetParameterHistoryErr != nil {
+		return nil, m.GetParameterHistoryErr
+	}
+	if m.GetParameterHistoryResps != nil {
+		resp := m.GetParameterHistoryResps[aws.StringValue(in.Name)]
+		return &resp, nil
+	}
 	return &m.GetParameterHistoryResp, nil
 }
 
@@ -106,9 +178,44 @@ func (m mockedSSM) GetParameters(in *ssm.GetParametersInput) (*ssm.GetParameters
 }
 
 func (m mockedSSM) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	if m.Calls != nil {
+		m.Calls.PutParameter = append(m.Calls.PutParameter, *in)
+	}
 	return &m.PutParameterResp, nil
 }
 
+func (m mockedSSM) AddTagsToResource(in *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	if m.Calls != nil {
+		m.Calls.AddTagsToResource = append(m.Calls.AddTagsToResource, *in)
+	}
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+func (m mockedSSM) RemoveTagsFromResource(in *ssm.RemoveTagsFromResourceInput) (*ssm.RemoveTagsFromResourceOutput, error) {
+	if m.Calls != nil {
+		m.Calls.RemoveTagsFromResource = append(m.Calls.RemoveTagsFromResource, *in)
+	}
+	return &ssm.RemoveTagsFromResourceOutput{}, nil
+}
+
+func (m mockedSSM) LabelParameterVersion(in *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	if m.Calls != nil {
+		m.Calls.LabelParameterVersion = append(m.Calls.LabelParameterVersion, *in)
+	}
+	return &ssm.LabelParameterVersionOutput{ParameterVersion: in.ParameterVersion}, nil
+}
+
+func (m mockedSSM) UnlabelParameterVersion(in *ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	if m.Calls != nil {
+		m.Calls.UnlabelParameterVersion = append(m.Calls.UnlabelParameterVersion, *in)
+	}
+	return &ssm.UnlabelParameterVersionOutput{RemovedLabels: in.Labels}, nil
+}
+
+func (m mockedSSM) ListTagsForResource(in *ssm.ListTagsForResourceInput) (*ssm.ListTagsForResourceOutput, error) {
+	return &m.ListTagsForResourceResp, nil
+}
+
 func TestPut(t *testing.T) {
 	var expectedVersion int64 = 1
 	var p parameterstore.ParameterStore
@@ -139,6 +246,39 @@ func TestPut(t *testing.T) {
 	}
 }
 
+func TestPutOverwriteWithTags(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{Calls: calls}
+	putParameterInput := ssm.PutParameterInput{
+		Name:      aws.String("/House/Stark/EddardStark"),
+		Value:     aws.String("Lord"),
+		Type:      aws.String("String"),
+		Overwrite: aws.Bool(true),
+		Tags: []*ssm.Tag{
+			{Key: aws.String("Status"), Value: aws.String("Deceased")},
+		},
+	}
+	_, err = p.Put(&putParameterInput, p.Region)
+	if err != nil {
+		t.Fatal("Error putting parameter", err)
+	}
+	if len(calls.PutParameter) != 1 || len(calls.PutParameter[0].Tags) != 0 {
+		t.Fatalf("expected one untagged PutParameter call, got %v", calls.PutParameter)
+	}
+	if len(calls.AddTagsToResource) != 1 || len(calls.AddTagsToResource[0].Tags) != 1 {
+		t.Fatalf("expected tags to be added separately, got %v", calls.AddTagsToResource)
+	}
+	if len(putParameterInput.Tags) != 1 {
+		t.Fatal("expected the caller's input to be left unmodified")
+	}
+}
+
 func TestMoveParameter(t *testing.T) {
 	srcParam := parameterstore.ParameterPath{
 		Name:   "/House/Stark/SansaStark",
@@ -191,7 +331,7 @@ func TestMoveParameter(t *testing.T) {
 			},
 		},
 	}
-	err = p.Move(srcParam, dstParam)
+	err = p.Move(srcParam, dstParam, parameterstore.CopyOptions{})
 	if err != nil {
 		t.Fatal("Error moving parameter", err)
 	}
@@ -268,7 +408,7 @@ func TestCopyPath(t *testing.T) {
 			NextToken: aws.String(""),
 		},
 	}
-	err = p.Copy(srcPath, dstPath, true)
+	err = p.Copy(srcPath, dstPath, true, parameterstore.CopyOptions{})
 	if err != nil {
 		t.Fatal("Error copying parameter path: ", err)
 	}
@@ -338,7 +478,7 @@ func TestCopyParameter(t *testing.T) {
 			},
 		},
 	}
-	err = p.Copy(srcParam, dstParam, false)
+	err = p.Copy(srcParam, dstParam, false, parameterstore.CopyOptions{})
 	if err != nil {
 		t.Fatal("Error copying parameter", err)
 	}
@@ -356,6 +496,147 @@ func TestCopyParameter(t *testing.T) {
 	}
 }
 
+func TestCopyParameterMetadata(t *testing.T) {
+	srcParam := parameterstore.ParameterPath{
+		Name:   "/House/Stark/AryaStark",
+		Region: "region",
+	}
+	dstParam := parameterstore.ParameterPath{
+		Name:   "/House/Braavos/AryaStark",
+		Region: "region",
+	}
+	expirationPolicy := `{"Type":"Expiration","Version":"1.0","Attributes":{"Timestamp":"2019-05-19T00:00:00.000Z"}}`
+	notificationPolicy := `{"Type":"ExpirationNotification","Version":"1.0","Attributes":{"Before":"14","Unit":"Days"}}`
+	cases := []struct {
+		Options          parameterstore.CopyOptions
+		ExpectedTier     string
+		ExpectedPolicies string
+		ExpectedTags     int
+	}{
+		{
+			Options:          parameterstore.CopyOptions{},
+			ExpectedTier:     ssm.ParameterTierAdvanced,
+			ExpectedPolicies: "[" + expirationPolicy + "," + notificationPolicy + "]",
+			ExpectedTags:     1,
+		},
+		{
+			Options:      parameterstore.CopyOptions{SkipTags: true, SkipPolicies: true},
+			ExpectedTier: ssm.ParameterTierAdvanced,
+		},
+		{
+			Options: parameterstore.CopyOptions{SkipTier: true},
+			// Policies cannot be copied without the advanced tier
+			ExpectedTags: 1,
+		},
+	}
+
+	for _, c := range cases {
+		var p parameterstore.ParameterStore
+		p.Region = "region"
+		err := p.NewParameterStore(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls := &mockCalls{}
+		p.Clients[p.Region] = mockedSSM{
+			GetParameterResp: []ssm.GetParameterOutput{
+				{
+					Parameter: &ssm.Parameter{
+						Name:  aws.String(srcParam.Name),
+						Type:  aws.String("String"),
+						Value: aws.String("No one"),
+					},
+				},
+			},
+			GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+				Parameters: []*ssm.ParameterHistory{
+					{
+						Name:     aws.String(srcParam.Name),
+						Value:    aws.String("No one"),
+						Type:     aws.String("String"),
+						DataType: aws.String("text"),
+						Tier:     aws.String(ssm.ParameterTierAdvanced),
+						Policies: []*ssm.ParameterInlinePolicy{
+							{PolicyText: aws.String(expirationPolicy)},
+							{PolicyText: aws.String(notificationPolicy)},
+						},
+						Version: aws.Int64(1),
+					},
+				},
+			},
+			ListTagsForResourceResp: ssm.ListTagsForResourceOutput{
+				TagList: []*ssm.Tag{
+					{Key: aws.String("Allegiance"), Value: aws.String("Many-Faced God")},
+				},
+			},
+			Calls: calls,
+		}
+		err = p.Copy(srcParam, dstParam, false, c.Options)
+		if err != nil {
+			t.Fatal("Error copying parameter", err)
+		}
+		if len(calls.PutParameter) != 1 {
+			t.Fatalf("expected 1 PutParameter call, got %d", len(calls.PutParameter))
+		}
+		put := calls.PutParameter[0]
+		if aws.StringValue(put.Name) != dstParam.Name {
+			t.Fatalf("expected %s, got %s", dstParam.Name, aws.StringValue(put.Name))
+		}
+		if aws.StringValue(put.DataType) != "text" {
+			t.Fatalf("expected data type text, got %s", aws.StringValue(put.DataType))
+		}
+		if aws.StringValue(put.Tier) != c.ExpectedTier {
+			t.Fatalf("expected tier %q, got %q", c.ExpectedTier, aws.StringValue(put.Tier))
+		}
+		if aws.StringValue(put.Policies) != c.ExpectedPolicies {
+			t.Fatalf("expected policies %q, got %q", c.ExpectedPolicies, aws.StringValue(put.Policies))
+		}
+		if len(put.Tags) != c.ExpectedTags {
+			t.Fatalf("expected %d tags, got %v", c.ExpectedTags, put.Tags)
+		}
+	}
+}
+
+func TestCopyOntoAdvanced(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	regions := fake.NewRegions()
+	p.NewClient = regions.Client
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	src := parameterstore.ParameterPath{Name: "/House/Stark/AryaStark", Region: "region"}
+	dst := parameterstore.ParameterPath{Name: "/House/Braavos/AryaStark", Region: "region"}
+	for _, put := range []struct{ Name, Value, Tier string }{
+		{src.Name, "No one", ssm.ParameterTierStandard},
+		{dst.Name, "Cat of the Canals", ssm.ParameterTierAdvanced},
+	} {
+		_, err = regions.Store("region").PutParameter(&ssm.PutParameterInput{
+			Name:  aws.String(put.Name),
+			Value: aws.String(put.Value),
+			Type:  aws.String("String"),
+			Tier:  aws.String(put.Tier),
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	p.Overwrite = true
+	err = p.Copy(src, dst, false, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error copying a standard parameter onto an advanced one", err)
+	}
+	history, err := p.GetHistory(dst)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	latest := history[len(history)-1]
+	if aws.StringValue(latest.Value) != "No one" || aws.StringValue(latest.Tier) != ssm.ParameterTierAdvanced {
+		t.Fatalf("expected the copied value in the advanced tier, got %+v", latest)
+	}
+}
+
 func TestCwd(t *testing.T) {
 	cases := []struct {
 		GetParametersByPathResp ssm.GetParametersByPathOutput
@@ -498,6 +779,123 @@ func TestGetHistory(t *testing.T) {
 	}
 }
 
+func TestGetHistorySelector(t *testing.T) {
+	getHistoryOutput := ssm.GetParameterHistoryOutput{
+		Parameters: []*ssm.ParameterHistory{
+			{
+				Name:    aws.String("/House/Stark/BranStark"),
+				Value:   aws.String("Boy"),
+				Version: aws.Int64(1),
+			},
+			{
+				Name:    aws.String("/House/Stark/BranStark"),
+				Value:   aws.String("Three-eyed raven"),
+				Version: aws.Int64(2),
+				Labels:  aws.StringSlice([]string{"season7"}),
+			},
+			{
+				Name:    aws.String("/House/Stark/BranStark"),
+				Value:   aws.String("King"),
+				Version: aws.Int64(3),
+			},
+		},
+	}
+	cases := []struct {
+		Name     string
+		Expected string
+		Error    bool
+	}{
+		{Name: "/House/Stark/BranStark:1", Expected: "Boy"},
+		{Name: "/House/Stark/BranStark:season7", Expected: "Three-eyed raven"},
+		{Name: "/House/Stark/BranStark:season8", Error: true},
+	}
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterHistoryResp: getHistoryOutput,
+	}
+	for _, c := range cases {
+		resp, err := p.GetHistory(parameterstore.ParameterPath{Name: c.Name, Region: "region"})
+		if c.Error {
+			if err == nil {
+				t.Fatalf("expected error for %s, got %v", c.Name, resp)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if len(resp) != 1 || aws.StringValue(resp[0].Value) != c.Expected {
+			t.Fatalf("expected %s, got %v", c.Expected, resp)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+			Parameters: []*ssm.ParameterHistory{
+				{
+					Name:    aws.String("/House/Stark/BranStark"),
+					Version: aws.Int64(1),
+					Labels:  aws.StringSlice([]string{"boy"}),
+				},
+				{
+					Name:    aws.String("/House/Stark/BranStark"),
+					Version: aws.Int64(2),
+					Labels:  aws.StringSlice([]string{"raven"}),
+				},
+			},
+		},
+		Calls: calls,
+	}
+
+	cases := []struct {
+		Name     string
+		Expected int64
+	}{
+		{Name: "/House/Stark/BranStark", Expected: 0},
+		{Name: "/House/Stark/BranStark:1", Expected: 1},
+		{Name: "/House/Stark/BranStark:raven", Expected: 2},
+	}
+	for i, c := range cases {
+		err = p.LabelParameterVersion(parameterstore.ParameterPath{Name: c.Name, Region: "region"}, []string{"king"})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		input := calls.LabelParameterVersion[i]
+		if aws.StringValue(input.Name) != "/House/Stark/BranStark" {
+			t.Fatalf("expected the selector to be removed from the name, got %s", aws.StringValue(input.Name))
+		}
+		if aws.Int64Value(input.ParameterVersion) != c.Expected {
+			t.Fatalf("expected version %d for %s, got %d", c.Expected, c.Name, aws.Int64Value(input.ParameterVersion))
+		}
+	}
+
+	err = p.UnlabelParameterVersion(parameterstore.ParameterPath{Name: "/House/Stark/BranStark", Region: "region"}, []string{"raven"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.UnlabelParameterVersion) != 1 || aws.Int64Value(calls.UnlabelParameterVersion[0].ParameterVersion) != 2 {
+		t.Fatalf("expected raven to be removed from version 2, got %v", calls.UnlabelParameterVersion)
+	}
+	err = p.UnlabelParameterVersion(parameterstore.ParameterPath{Name: "/House/Stark/BranStark", Region: "region"}, []string{"queen"})
+	if err == nil {
+		t.Fatal("expected error removing a label that is not attached")
+	}
+}
+
 func TestList(t *testing.T) {
 	cases := []struct {
 		Query                   parameterstore.ParameterPath
@@ -631,3 +1029,2059 @@ func equal(first []string, second []string) bool {
 	}
 	return true
 }
+
+func TestTags(t *testing.T) {
+	houseStark := parameterstore.ParameterPath{
+		Name:   "/House/Stark",
+		Region: "region",
+	}
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResp: ssm.GetParametersByPathOutput{
+			Parameters: HouseStark,
+			NextToken:  aws.String(""),
+		},
+		ListTagsForResourceResp: ssm.ListTagsForResourceOutput{
+			TagList: []*ssm.Tag{
+				{Key: aws.String("Words"), Value: aws.String("Winter is coming")},
+			},
+		},
+		Calls: calls,
+	}
+
+	tags := []*ssm.Tag{{Key: aws.String("Seat"), Value: aws.String("Winterfell")}}
+	err = p.AddTags([]parameterstore.ParameterPath{houseStark}, tags, false)
+	if err == nil {
+		t.Fatal("expected error when tagging a path without recursion")
+	}
+	err = p.AddTags([]parameterstore.ParameterPath{houseStark}, tags, true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.AddTagsToResource) != len(HouseStark) {
+		t.Fatalf("expected %d tagged parameters, got %d", len(HouseStark), len(calls.AddTagsToResource))
+	}
+	if aws.StringValue(calls.AddTagsToResource[0].ResourceType) != ssm.ResourceTypeForTaggingParameter {
+		t.Fatalf("expected resource type %s, got %s", ssm.ResourceTypeForTaggingParameter, aws.StringValue(calls.AddTagsToResource[0].ResourceType))
+	}
+
+	err = p.RemoveTags([]parameterstore.ParameterPath{houseStark}, []string{"Seat"}, true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.RemoveTagsFromResource) != len(HouseStark) {
+		t.Fatalf("expected %d untagged parameters, got %d", len(HouseStark), len(calls.RemoveTagsFromResource))
+	}
+
+	resp, err := p.ListTags([]parameterstore.ParameterPath{houseStark}, true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(resp) != len(HouseStark) {
+		t.Fatalf("expected tags for %d parameters, got %v", len(HouseStark), resp)
+	}
+	if resp[0].Name != aws.StringValue(EddardStark.Name) || aws.StringValue(resp[0].Tags[0].Key) != "Words" {
+		t.Fatalf("unexpected tags %v", resp[0])
+	}
+}
+
+func TestRollback(t *testing.T) {
+	param := parameterstore.ParameterPath{
+		Name:   "/House/Lannister/TyrionLannister",
+		Region: "region",
+	}
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+			Parameters: []*ssm.ParameterHistory{
+				{
+					Name:        aws.String(param.Name),
+					Value:       aws.String("Master of Coin"),
+					Type:        aws.String("SecureString"),
+					KeyId:       aws.String("alias/casterly-rock"),
+					Description: aws.String("Season 1"),
+					Tier:        aws.String(ssm.ParameterTierStandard),
+					Version:     aws.Int64(1),
+					Labels:      aws.StringSlice([]string{"season1"}),
+				},
+				{
+					Name:    aws.String(param.Name),
+					Value:   aws.String("Hand of the King"),
+					Type:    aws.String("SecureString"),
+					Tier:    aws.String(ssm.ParameterTierStandard),
+					Version: aws.Int64(2),
+				},
+				{
+					Name:    aws.String(param.Name),
+					Value:   aws.String("Prisoner"),
+					Type:    aws.String("String"),
+					Tier:    aws.String(ssm.ParameterTierStandard),
+					Version: aws.Int64(3),
+				},
+			},
+		},
+		PutParameterResp: ssm.PutParameterOutput{Version: aws.Int64(4)},
+		Calls:            calls,
+	}
+
+	latest, target, err := p.RollbackTarget(param)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.Int64Value(latest.Version) != 3 || aws.Int64Value(target.Version) != 2 {
+		t.Fatalf("expected rollback from version 3 to 2, got %d to %d", aws.Int64Value(latest.Version), aws.Int64Value(target.Version))
+	}
+	if p.Decrypt {
+		t.Fatal("expected decryption setting to be restored")
+	}
+
+	_, target, err = p.RollbackTarget(parameterstore.ParameterPath{Name: param.Name + ":season1", Region: "region"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	resp, err := p.Rollback(param, latest, target)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.Int64Value(resp.Version) != 4 {
+		t.Fatalf("expected version 4, got %d", aws.Int64Value(resp.Version))
+	}
+	put := calls.PutParameter[0]
+	if aws.StringValue(put.Value) != "Master of Coin" ||
+		aws.StringValue(put.KeyId) != "alias/casterly-rock" ||
+		aws.StringValue(put.Description) != "Season 1" ||
+		aws.StringValue(put.Tier) != ssm.ParameterTierStandard ||
+		!aws.BoolValue(put.Overwrite) {
+		t.Fatalf("unexpected rollback input %v", put)
+	}
+
+	// Advanced parameters cannot be downgraded, so the tier of a standard version is not put
+	latest.Tier = aws.String(ssm.ParameterTierAdvanced)
+	_, err = p.Rollback(param, latest, target)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if put := calls.PutParameter[1]; put.Tier != nil {
+		t.Fatalf("expected no tier rolling back an advanced parameter, got %s", aws.StringValue(put.Tier))
+	}
+
+	_, _, err = p.RollbackTarget(parameterstore.ParameterPath{Name: param.Name + ":3", Region: "region"})
+	if err == nil {
+		t.Fatal("expected error rolling back to the latest version")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/Season1/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/Season1/Stark/EddardStark"), Type: aws.String("String"), Value: aws.String("Lord")},
+					{Name: aws.String("/Season1/Stark/RobStark"), Type: aws.String("String"), Value: aws.String("Heir")},
+					{Name: aws.String("/Season1/Stark/JonSnow"), Type: aws.String("SecureString"), Value: aws.String("Bastard")},
+				},
+			},
+			"/Season8/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/Season8/Stark/RobStark"), Type: aws.String("String"), Value: aws.String("Heir")},
+					{Name: aws.String("/Season8/Stark/JonSnow"), Type: aws.String("SecureString"), Value: aws.String("Aegon Targaryen")},
+					{Name: aws.String("/Season8/Stark/SansaStark"), Type: aws.String("String"), Value: aws.String("Queen")},
+				},
+			},
+		},
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/Season1/Stark": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/Season1/Stark/RobStark"), Tier: aws.String(ssm.ParameterTierStandard)},
+				},
+			},
+			"/Season8/Stark": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/Season8/Stark/RobStark"), Tier: aws.String(ssm.ParameterTierAdvanced)},
+				},
+			},
+		},
+	}
+
+	diffs, err := p.Diff(
+		parameterstore.ParameterPath{Name: "/Season1/Stark", Region: "region"},
+		parameterstore.ParameterPath{Name: "/Season8/Stark", Region: "region"},
+		true,
+	)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if p.Decrypt {
+		t.Fatal("expected decryption setting to be restored")
+	}
+	expected := []struct {
+		Name    string
+		InSrc   bool
+		InDst   bool
+		Changes []string
+	}{
+		{Name: "EddardStark", InSrc: true},
+		{Name: "JonSnow", InSrc: true, InDst: true, Changes: []string{"Value"}},
+		{Name: "RobStark", InSrc: true, InDst: true, Changes: []string{"Tier"}},
+		{Name: "SansaStark", InDst: true},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d differences, got %v", len(expected), diffs)
+	}
+	for i, e := range expected {
+		d := diffs[i]
+		if d.Name != e.Name || (d.Src != nil) != e.InSrc || (d.Dst != nil) != e.InDst {
+			t.Fatalf("expected %+v, got %+v", e, d)
+		}
+		var changes []string
+		for _, c := range d.Changes {
+			changes = append(changes, c.Field)
+		}
+		if !equal(changes, e.Changes) {
+			t.Fatalf("expected changes %v for %s, got %v", e.Changes, e.Name, changes)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/Season1/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/Season1/Stark/EddardStark"), Type: aws.String("String"), Value: aws.String("Lord")},
+					{Name: aws.String("/Season1/Stark/RobStark"), Type: aws.String("String"), Value: aws.String("Heir")},
+					{Name: aws.String("/Season1/Stark/JonSnow"), Type: aws.String("String"), Value: aws.String("Bastard")},
+				},
+			},
+		},
+		GetParameterResp: []ssm.GetParameterOutput{
+			{Parameter: &ssm.Parameter{Name: aws.String("/Season1/Stark/EddardStark")}},
+			{Parameter: &ssm.Parameter{Name: aws.String("/Season1/Stark/JonSnow")}},
+		},
+		GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+			Parameters: []*ssm.ParameterHistory{
+				{Name: aws.String("/Season1/Stark/EddardStark"), Type: aws.String("SecureString"), Value: aws.String("Lord"), KeyId: aws.String("alias/winterfell")},
+			},
+		},
+	}
+	calls := &mockCalls{}
+	p.Clients["dr@region"] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/Season1/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/Season1/Stark/RobStark"), Type: aws.String("String"), Value: aws.String("Heir")},
+					{Name: aws.String("/Season1/Stark/JonSnow"), Type: aws.String("String"), Value: aws.String("Aegon Targaryen")},
+					{Name: aws.String("/Season1/Stark/SansaStark"), Type: aws.String("String"), Value: aws.String("Queen")},
+				},
+			},
+		},
+		Calls: calls,
+	}
+
+	src := parameterstore.ParameterPath{Name: "/Season1/Stark", Region: "region"}
+	dst := parameterstore.ParameterPath{Name: "/Season1/Stark", Region: "region", Profile: "dr"}
+	expected := []struct {
+		Action string
+		Name   string
+	}{
+		{parameterstore.SyncCreate, "/Season1/Stark/EddardStark"},
+		{parameterstore.SyncUpdate, "/Season1/Stark/JonSnow"},
+		{parameterstore.SyncDelete, "/Season1/Stark/SansaStark"},
+	}
+
+	plan, err := p.SyncPlan(src, dst, false, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(plan) != 2 {
+		t.Fatalf("expected 2 actions without delete, got %+v", plan)
+	}
+
+	plan, err = p.SyncPlan(src, dst, true, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(plan) != len(expected) {
+		t.Fatalf("expected %d actions, got %+v", len(expected), plan)
+	}
+	for i, e := range expected {
+		if plan[i].Action != e.Action || plan[i].Dst.Name != e.Name || plan[i].Dst.Profile != "dr" {
+			t.Fatalf("expected %+v, got %+v", e, plan[i])
+		}
+	}
+	if !equal(plan[1].Changes, []string{"Value"}) {
+		t.Fatalf("expected Value to change, got %v", plan[1].Changes)
+	}
+
+	err = p.Sync(plan, parameterstore.CopyOptions{SkipTags: true})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if p.Overwrite || p.Decrypt {
+		t.Fatal("expected overwrite and decryption settings to be restored")
+	}
+	if len(calls.PutParameter) != 2 {
+		t.Fatalf("expected 2 puts to the destination, got %d", len(calls.PutParameter))
+	}
+	if !aws.BoolValue(calls.PutParameter[0].Overwrite) {
+		t.Fatal("expected sync to overwrite")
+	}
+	if calls.PutParameter[0].KeyId != nil {
+		t.Fatalf("expected the KMS key of another profile not to be copied, got %s", aws.StringValue(calls.PutParameter[0].KeyId))
+	}
+	if len(calls.DeleteParameters) != 1 || aws.StringValue(calls.DeleteParameters[0].Names[0]) != "/Season1/Stark/SansaStark" {
+		t.Fatalf("expected SansaStark to be deleted, got %+v", calls.DeleteParameters)
+	}
+}
+
+func TestSyncOntoAdvanced(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	regions := fake.NewRegions()
+	p.NewClient = regions.Client
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	for _, put := range []struct{ Name, Value, Tier string }{
+		{"/Season1/Stark/RobStark", "Heir", ssm.ParameterTierStandard},
+		{"/Season3/Stark/RobStark", "King in the North", ssm.ParameterTierAdvanced},
+	} {
+		_, err = regions.Store("region").PutParameter(&ssm.PutParameterInput{
+			Name:  aws.String(put.Name),
+			Value: aws.String(put.Value),
+			Type:  aws.String("String"),
+			Tier:  aws.String(put.Tier),
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	src := parameterstore.ParameterPath{Name: "/Season1/Stark", Region: "region"}
+	dst := parameterstore.ParameterPath{Name: "/Season3/Stark", Region: "region"}
+	plan, err := p.SyncPlan(src, dst, false, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(plan) != 1 || !equal(plan[0].Changes, []string{"Value"}) {
+		t.Fatalf("expected only the value to be updated, got %+v", plan)
+	}
+	err = p.Sync(plan, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error syncing onto an advanced parameter", err)
+	}
+	plan, err = p.SyncPlan(src, dst, false, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(plan) != 0 {
+		t.Fatalf("expected nothing to sync after syncing, got %+v", plan)
+	}
+}
+
+func TestSyncPlanOptions(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	policies := []*ssm.ParameterInlinePolicy{{PolicyText: aws.String(`{"Type":"Expiration"}`), PolicyType: aws.String("Expiration")}}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/Dragonstone": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/Dragonstone/Daenerys"), Type: aws.String("SecureString"), Value: aws.String("Queen")},
+					{Name: aws.String("/Dragonstone/Dragons"), Type: aws.String("String"), Value: aws.String("3")},
+				},
+			},
+		},
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/Dragonstone": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/Dragonstone/Daenerys"), KeyId: aws.String("arn:aws:kms:region:123456789012:key/1"), Tier: aws.String(ssm.ParameterTierAdvanced), Policies: policies},
+					{Name: aws.String("/Dragonstone/Dragons"), Tier: aws.String(ssm.ParameterTierStandard)},
+				},
+			},
+		},
+	}
+	p.Clients["other"] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/Dragonstone": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/Dragonstone/Daenerys"), Type: aws.String("SecureString"), Value: aws.String("Queen")},
+					{Name: aws.String("/Dragonstone/Dragons"), Type: aws.String("String"), Value: aws.String("2")},
+				},
+			},
+		},
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/Dragonstone": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/Dragonstone/Daenerys"), KeyId: aws.String("arn:aws:kms:other:123456789012:key/2"), Tier: aws.String(ssm.ParameterTierStandard)},
+					{Name: aws.String("/Dragonstone/Dragons"), Tier: aws.String(ssm.ParameterTierStandard)},
+				},
+			},
+		},
+	}
+	src := parameterstore.ParameterPath{Name: "/Dragonstone", Region: "region"}
+	dst := parameterstore.ParameterPath{Name: "/Dragonstone", Region: "other"}
+
+	tests := []struct {
+		name    string
+		opts    parameterstore.CopyOptions
+		changes map[string][]string // Expected changes keyed by destination name
+	}{
+		{
+			name: "all fields",
+			changes: map[string][]string{
+				"/Dragonstone/Daenerys": {"Tier", "Policies"},
+				"/Dragonstone/Dragons":  {"Value"},
+			},
+		},
+		{
+			name: "no policies",
+			opts: parameterstore.CopyOptions{SkipPolicies: true},
+			changes: map[string][]string{
+				"/Dragonstone/Daenerys": {"Tier"},
+				"/Dragonstone/Dragons":  {"Value"},
+			},
+		},
+		{
+			name:    "no tier",
+			opts:    parameterstore.CopyOptions{SkipTier: true},
+			changes: map[string][]string{"/Dragonstone/Dragons": {"Value"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan, err := p.SyncPlan(src, dst, false, test.opts)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if len(plan) != len(test.changes) {
+				t.Fatalf("expected %d actions, got %+v", len(test.changes), plan)
+			}
+			for _, action := range plan {
+				if !equal(action.Changes, test.changes[action.Dst.Name]) {
+					t.Errorf("expected %s to change %v, got %v", action.Dst.Name, test.changes[action.Dst.Name], action.Changes)
+				}
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/House/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/House/Stark/Winterfell/lord"), Type: aws.String("String"), Value: aws.String("Eddard")},
+					{Name: aws.String("/House/Stark/sigil"), Type: aws.String("String"), Value: aws.String("Direwolf")},
+					{Name: aws.String("/House/Stark/words"), Type: aws.String("SecureString"), Value: aws.String("Winter's coming")},
+				},
+			},
+		},
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/House/Stark": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/House/Stark/words"), KeyId: aws.String("alias/aws/ssm"), Tier: aws.String(ssm.ParameterTierStandard)},
+				},
+			},
+		},
+	}
+	params, err := p.Export(parameterstore.ParameterPath{Name: "/House/Stark", Region: "region"}, true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if p.Decrypt {
+		t.Fatal("expected decryption setting to be restored")
+	}
+	if len(params) != 3 || params[0].Name != "Winterfell/lord" || params[2].Key != "alias/aws/ssm" {
+		t.Fatalf("unexpected export %+v", params)
+	}
+
+	expected := map[string]string{
+		parameterstore.FormatDotenv: "WINTERFELL_LORD=\"Eddard\"\nSIGIL=\"Direwolf\"\nWORDS=\"Winter's coming\"\n",
+		parameterstore.FormatShell:  "export WINTERFELL_LORD='Eddard'\nexport SIGIL='Direwolf'\nexport WORDS='Winter'\\''s coming'\n",
+		parameterstore.FormatTfvars: "winterfell_lord = \"Eddard\"\nsigil = \"Direwolf\"\nwords = \"Winter's coming\"\n",
+	}
+	for format, e := range expected {
+		b, err := parameterstore.MarshalParameters(params, format)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if string(b) != e {
+			t.Fatalf("expected %s output %q, got %q", format, e, string(b))
+		}
+	}
+
+	b, err := parameterstore.MarshalParameters(params, parameterstore.FormatJSON)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var tree map[string]map[string]interface{}
+	err = json.Unmarshal(b, &tree)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	lord, ok := tree["Winterfell"]["lord"].(map[string]interface{})
+	if !ok || lord["value"] != "Eddard" || lord["type"] != "String" {
+		t.Fatalf("expected nested parameters, got %s", string(b))
+	}
+
+	_, err = parameterstore.MarshalParameters(params, "xml")
+	if err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestExportImportTags(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	regions := fake.NewRegions()
+	p.NewClient = regions.Client
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = regions.Store("region").PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/House/Stark/sigil"),
+		Value: aws.String("Direwolf"),
+		Type:  aws.String("String"),
+		Tags:  []*ssm.Tag{{Key: aws.String("Seat"), Value: aws.String("Winterfell")}},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	params, err := p.Export(parameterstore.ParameterPath{Name: "/House/Stark", Region: "region"}, true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(params) != 1 || params[0].Tags["Seat"] != "Winterfell" {
+		t.Fatalf("expected the tags to be exported, got %+v", params)
+	}
+
+	b, err := parameterstore.MarshalParameters(params, parameterstore.FormatJSON)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	params, err = parameterstore.UnmarshalParameters(b, parameterstore.FormatJSON)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	prefix := parameterstore.ParameterPath{Name: "/House/Imported", Region: "region"}
+	_, err = p.Import(params, prefix, parameterstore.ImportOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	tags, err := regions.Store("region").ListTagsForResource(&ssm.ListTagsForResourceInput{
+		ResourceId:   aws.String("/House/Imported/sigil"),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(tags.TagList) != 1 || aws.StringValue(tags.TagList[0].Value) != "Winterfell" {
+		t.Fatalf("expected the tags to be imported, got %v", tags.TagList)
+	}
+}
+
+func TestExportCollisions(t *testing.T) {
+	tests := []struct {
+		names   []string
+		formats []string
+	}{
+		// A parameter that is also a path, with a child named like its metadata
+		{[]string{"Winterfell", "Winterfell/value"}, []string{parameterstore.FormatJSON, parameterstore.FormatYAML}},
+		{[]string{"Winterfell", "Winterfell/type/lord"}, []string{parameterstore.FormatJSON, parameterstore.FormatYAML}},
+		// Names that convert to the same variable
+		{[]string{"night-watch", "night_watch"}, []string{parameterstore.FormatDotenv, parameterstore.FormatShell, parameterstore.FormatTfvars}},
+	}
+	for _, test := range tests {
+		var params []parameterstore.ExportedParameter
+		for _, name := range test.names {
+			params = append(params, parameterstore.ExportedParameter{Name: name, Value: name, Type: "String"})
+		}
+		for _, format := range test.formats {
+			_, err := parameterstore.MarshalParameters(params, format)
+			if err == nil {
+				t.Errorf("expected an error exporting %v as %s", test.names, format)
+			}
+		}
+	}
+
+	// Other children of a parameter are nested alongside its metadata
+	params := []parameterstore.ExportedParameter{
+		{Name: "Winterfell", Value: "Castle", Type: "String"},
+		{Name: "Winterfell/lord", Value: "Eddard", Type: "String"},
+	}
+	b, err := parameterstore.MarshalParameters(params, parameterstore.FormatJSON)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	roundTrip, err := parameterstore.UnmarshalParameters(b, parameterstore.FormatJSON)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(roundTrip) != 2 {
+		t.Fatalf("expected both parameters to be imported, got %+v", roundTrip)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	cases := map[string]string{
+		"db/read-url":  "DB_READ_URL",
+		"app.name":     "APP_NAME",
+		"2fa/secret":   "_2FA_SECRET",
+		"/leading/key": "LEADING_KEY",
+	}
+	for name, expected := range cases {
+		if got := parameterstore.EnvName(name); got != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, name, got)
+		}
+	}
+}
+
+func TestUnmarshalParameters(t *testing.T) {
+	jsonData := `{
+		"Winterfell": {
+			"lord": {"value": "Eddard", "type": "String", "tier": "standard", "tags": {"House": "Stark"}},
+			"bannermen": ["Karstark", "Umber"]
+		},
+		"sigil": "Direwolf"
+	}`
+	yamlData := `
+Winterfell:
+    lord:
+        value: Eddard
+        type: String
+        tier: standard
+        tags:
+            House: Stark
+    bannermen:
+        - Karstark
+        - Umber
+sigil: Direwolf
+`
+	for format, data := range map[string]string{parameterstore.FormatJSON: jsonData, parameterstore.FormatYAML: yamlData} {
+		params, err := parameterstore.UnmarshalParameters([]byte(data), format)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if len(params) != 3 {
+			t.Fatalf("expected 3 parameters from %s, got %+v", format, params)
+		}
+		if params[0].Name != "Winterfell/bannermen" || params[0].Value != "Karstark,Umber" || params[0].Type != ssm.ParameterTypeStringList {
+			t.Fatalf("unexpected list parameter from %s %+v", format, params[0])
+		}
+		if params[1].Name != "Winterfell/lord" || params[1].Tier != "standard" || params[1].Tags["House"] != "Stark" {
+			t.Fatalf("unexpected parameter from %s %+v", format, params[1])
+		}
+		if params[2].Name != "sigil" || params[2].Value != "Direwolf" || params[2].Type != "" {
+			t.Fatalf("unexpected scalar parameter from %s %+v", format, params[2])
+		}
+	}
+
+	dotenv := "# Stark\nLORD=\"Eddard\\nStark\"\nexport WORDS='Winter'\\''s coming'\nSIGIL=Direwolf\n"
+	params, err := parameterstore.UnmarshalParameters([]byte(dotenv), parameterstore.FormatDotenv)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	expected := []string{"LORD=Eddard\nStark", "WORDS=Winter's coming", "SIGIL=Direwolf"}
+	if len(params) != len(expected) {
+		t.Fatalf("expected %d parameters, got %+v", len(expected), params)
+	}
+	for i, e := range expected {
+		if params[i].Name+"="+params[i].Value != e {
+			t.Fatalf("expected %q, got %+v", e, params[i])
+		}
+	}
+
+	_, err = parameterstore.UnmarshalParameters([]byte("NOVALUE\n"), parameterstore.FormatDotenv)
+	if err == nil {
+		t.Fatal("expected error for a line without a value")
+	}
+}
+
+func TestImport(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.Type = "String"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/House/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/House/Stark/sigil"), Type: aws.String("String"), Value: aws.String("Direwolf")},
+					{Name: aws.String("/House/Stark/lord"), Type: aws.String("String"), Value: aws.String("Eddard")},
+				},
+			},
+		},
+		Calls: calls,
+	}
+	params := []parameterstore.ExportedParameter{
+		{Name: "sigil", Value: "Direwolf"},
+		{Name: "lord", Value: "Robb"},
+		{Name: "words", Value: "Winter is coming", Type: "securestring", Tags: map[string]string{"House": "Stark"}},
+	}
+	prefix := parameterstore.ParameterPath{Name: "/House/Stark", Region: "region"}
+
+	result, err := p.Import(params, prefix, parameterstore.ImportOptions{DryRun: true, Overwrite: true})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.PutParameter) != 0 {
+		t.Fatal("expected no puts during a dry run")
+	}
+	if len(result.Created) != 1 || len(result.Updated) != 1 || len(result.Unchanged) != 1 {
+		t.Fatalf("unexpected dry run result %+v", result)
+	}
+
+	result, err = p.Import(params, prefix, parameterstore.ImportOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if p.Decrypt {
+		t.Fatal("expected decryption setting to be restored")
+	}
+	if !equal(result.Created, []string{"/House/Stark/words"}) || !equal(result.Skipped, []string{"/House/Stark/lord"}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(calls.PutParameter) != 1 {
+		t.Fatalf("expected 1 put, got %d", len(calls.PutParameter))
+	}
+	put := calls.PutParameter[0]
+	if aws.StringValue(put.Type) != ssm.ParameterTypeSecureString || len(put.Tags) != 1 || aws.BoolValue(put.Overwrite) {
+		t.Fatalf("unexpected put %+v", put)
+	}
+
+	_, err = p.Import([]parameterstore.ExportedParameter{{Name: "sigil", Value: "Direwolf", Type: "Number"}}, prefix, parameterstore.ImportOptions{})
+	if err == nil {
+		t.Fatal("expected error for an invalid type")
+	}
+}
+
+type mockedKMS struct {
+	kmsiface.KMSAPI
+}
+
+func (m mockedKMS) GenerateDataKey(in *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	key := bytes.Repeat([]byte{7}, 32)
+	return &kms.GenerateDataKeyOutput{KeyId: in.KeyId, Plaintext: key, CiphertextBlob: append([]byte("wrapped"), key...)}, nil
+}
+
+func (m mockedKMS) Decrypt(in *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	return &kms.DecryptOutput{KeyId: in.KeyId, Plaintext: bytes.TrimPrefix(in.CiphertextBlob, []byte("wrapped"))}, nil
+}
+
+func TestBackupRestore(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/House/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/House/Stark/EddardStark"), Type: aws.String("String"), Value: aws.String("Hand")},
+				},
+			},
+		},
+		GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+			Parameters: []*ssm.ParameterHistory{
+				{Name: aws.String("/House/Stark/EddardStark"), Type: aws.String("String"), Value: aws.String("Lord"), Version: aws.Int64(1), Labels: aws.StringSlice([]string{"winterfell"})},
+				{Name: aws.String("/House/Stark/EddardStark"), Type: aws.String("String"), Value: aws.String("Hand"), Version: aws.Int64(2), Tier: aws.String(ssm.ParameterTierAdvanced),
+					Policies: []*ssm.ParameterInlinePolicy{{PolicyText: aws.String(`{"Type":"Expiration"}`)}}},
+			},
+		},
+		ListTagsForResourceResp: ssm.ListTagsForResourceOutput{
+			TagList: []*ssm.Tag{{Key: aws.String("House"), Value: aws.String("Stark")}},
+		},
+	}
+	p.KMSClients = map[string]kmsiface.KMSAPI{"region": mockedKMS{}}
+
+	b, err := p.Backup(parameterstore.ParameterPath{Name: "/House/Stark", Region: "region"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if p.Decrypt {
+		t.Fatal("expected decryption setting to be restored")
+	}
+	if len(b.Parameters) != 1 || b.Parameters[0].Name != "EddardStark" || len(b.Parameters[0].History) != 2 || len(b.Parameters[0].Tags) != 1 {
+		t.Fatalf("unexpected backup %+v", b)
+	}
+
+	passphrase := func() ([]byte, error) { return []byte("Ice"), nil }
+	keys := []parameterstore.BackupKey{
+		{Passphrase: []byte("Ice")},
+		{KMSKeyID: "alias/backups", KMSPath: parameterstore.ParameterPath{Region: "region"}},
+	}
+	for _, key := range keys {
+		var buf bytes.Buffer
+		err = p.WriteBackup(&buf, b, key)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if bytes.Contains(buf.Bytes(), []byte("Lord")) {
+			t.Fatal("expected backup to be encrypted")
+		}
+		read, err := p.ReadBackup(bytes.NewReader(buf.Bytes()), passphrase)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if read.Path != "/House/Stark" || aws.StringValue(read.Parameters[0].History[0].Value) != "Lord" {
+			t.Fatalf("unexpected backup read %+v", read)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = p.WriteBackup(&buf, b, keys[0])
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = p.ReadBackup(bytes.NewReader(buf.Bytes()), func() ([]byte, error) { return []byte("Fire"), nil })
+	if err == nil {
+		t.Fatal("expected error with the wrong passphrase")
+	}
+
+	// Cost parameters from the header are checked before deriving a key
+	header := []byte(`"N":32768,"R":8,"P":1`)
+	if !bytes.Contains(buf.Bytes(), header) {
+		t.Fatalf("expected scrypt parameters in the header, got %q", buf.String())
+	}
+	for _, params := range []string{
+		`"N":2147483648,"R":8,"P":1`,
+		`"N":1048576,"R":1024,"P":1`,
+		`"N":32768,"R":1,"P":1073741824`,
+		`"N":32767,"R":8,"P":1`,
+		`"N":32768,"R":0,"P":1`,
+	} {
+		crafted := bytes.Replace(buf.Bytes(), header, []byte(params), 1)
+		_, err = p.ReadBackup(bytes.NewReader(crafted), func() ([]byte, error) {
+			t.Fatalf("expected %s to be rejected before asking for the passphrase", params)
+			return nil, nil
+		})
+		if err == nil {
+			t.Fatalf("expected an error for %s", params)
+		}
+	}
+
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		PutParameterResp: ssm.PutParameterOutput{Version: aws.Int64(1)},
+		Calls:            calls,
+	}
+	versions, err := p.Restore(b, parameterstore.ParameterPath{Name: "/Restored/Stark", Region: "region"}, false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if versions != 2 || len(calls.PutParameter) != 2 {
+		t.Fatalf("expected 2 versions restored, got %d", versions)
+	}
+	first, latest := calls.PutParameter[0], calls.PutParameter[1]
+	if aws.BoolValue(first.Overwrite) || !aws.BoolValue(latest.Overwrite) {
+		t.Fatal("expected only versions after the first to overwrite")
+	}
+	if aws.StringValue(first.Name) != "/Restored/Stark/EddardStark" || aws.StringValue(first.Value) != "Lord" || aws.StringValue(latest.Value) != "Hand" {
+		t.Fatalf("expected versions in order, got %+v", calls.PutParameter)
+	}
+	if first.Policies != nil || latest.Policies == nil {
+		t.Fatal("expected policies on the latest version only")
+	}
+	if len(calls.LabelParameterVersion) != 1 || len(calls.AddTagsToResource) != 1 {
+		t.Fatalf("expected labels and tags to be restored, got %+v", calls)
+	}
+
+	// KMS keys belong to the region and account of the backup
+	b.Parameters[0].History[1].KeyId = aws.String("alias/winterfell")
+	for _, to := range []parameterstore.ParameterPath{
+		{Name: "/Restored/Stark", Region: "region"},
+		{Name: "/Restored/Stark", Region: "other"},
+		{Name: "/Restored/Stark", Region: "region", Profile: "dr"},
+	} {
+		calls := &mockCalls{}
+		p.Clients[p.ClientKey(to)] = mockedSSM{
+			PutParameterResp: ssm.PutParameterOutput{Version: aws.Int64(1)},
+			Calls:            calls,
+		}
+		_, err = p.Restore(b, to, false)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		keyID := aws.StringValue(calls.PutParameter[1].KeyId)
+		if (p.ClientKey(to) == "region") != (keyID == "alias/winterfell") {
+			t.Fatalf("unexpected KMS key %q restoring to %s", keyID, p.ClientKey(to))
+		}
+	}
+
+	// A corrupted header is reported rather than panicking
+	var good bytes.Buffer
+	err = p.WriteBackup(&good, b, keys[0])
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var goodHeader map[string]interface{}
+	lines := bytes.SplitN(good.Bytes(), []byte("\n"), 3)
+	err = json.Unmarshal(lines[1], &goodHeader)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	for _, corrupt := range []func(map[string]interface{}){
+		func(h map[string]interface{}) { h["Nonce"] = "AAAA" },
+		func(h map[string]interface{}) { delete(h, "Nonce") },
+		func(h map[string]interface{}) { delete(h, "Salt") },
+	} {
+		crafted := make(map[string]interface{})
+		for k, v := range goodHeader {
+			crafted[k] = v
+		}
+		corrupt(crafted)
+		headerJSON, err := json.Marshal(crafted)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		file := bytes.Join([][]byte{lines[0], headerJSON, lines[2]}, []byte("\n"))
+		_, err = p.ReadBackup(bytes.NewReader(file), passphrase)
+		if err == nil || !strings.Contains(err.Error(), "invalid backup") {
+			t.Fatalf("expected an invalid backup error for header %s, got %v", headerJSON, err)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	names := []string{
+		"/House/Stark/EddardStark",
+		"/House/Stark/JonSnow",
+		"/House/Stark/Winterfell/RobbStark",
+		"/House/Lannister/JaimeLannister",
+		"/House/Targaryen/Daenerys",
+	}
+	var params []*ssm.Parameter
+	var resps []ssm.GetParameterOutput
+	for _, n := range names {
+		param := &ssm.Parameter{Name: aws.String(n), Type: aws.String("String"), Value: aws.String("Westeros")}
+		params = append(params, param)
+		resps = append(resps, ssm.GetParameterOutput{Parameter: param})
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/":            {Parameters: params},
+			"/House":       {Parameters: params},
+			"/House/Stark": {Parameters: params[:3]},
+		},
+		GetParameterResp: resps,
+		GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+			Parameters: []*ssm.ParameterHistory{{Type: aws.String("String"), Value: aws.String("Westeros")}},
+		},
+		Calls: calls,
+	}
+
+	cases := []struct {
+		Pattern  string
+		Cwd      string
+		Expected []string
+	}{
+		{"/House/*/*Stark", "/", []string{"/House/Stark/EddardStark"}},
+		{"/House/Stark/**/*Stark", "/", []string{"/House/Stark/EddardStark", "/House/Stark/Winterfell/RobbStark"}},
+		{"/House/{Stark,Lannister}/J?*", "/", []string{"/House/Lannister/JaimeLannister", "/House/Stark/JonSnow"}},
+		{"re:/House/.*/(Jon|Robb).*", "/", []string{"/House/Stark/JonSnow", "/House/Stark/Winterfell/RobbStark"}},
+		{"re:/House/Stark/Jon.*|/House/Targaryen/.*", "/", []string{"/House/Stark/JonSnow", "/House/Targaryen/Daenerys"}},
+		{"re:Stark/Jon.*|Targaryen/.*", "/House", []string{"/House/Stark/JonSnow", "/House/Targaryen/Daenerys"}},
+		{"*/Daenerys", "/House", []string{"/House/Targaryen/Daenerys"}},
+		{"/House/*/Tyrion*", "/", nil},
+	}
+	for _, c := range cases {
+		p.Cwd = c.Cwd
+		matches, err := p.Expand(parameterstore.ParameterPath{Name: c.Pattern, Region: "region"})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Name)
+		}
+		if !equal(got, c.Expected) {
+			t.Fatalf("expected %s to match %v, got %v", c.Pattern, c.Expected, got)
+		}
+	}
+
+	_, err = p.Expand(parameterstore.ParameterPath{Name: "/House/{Stark", Region: "region"})
+	if err == nil {
+		t.Fatal("expected error for an unmatched brace")
+	}
+
+	p.Cwd = parameterstore.Delimiter
+	err = p.CopyPattern(
+		parameterstore.ParameterPath{Name: "/House/*/J*", Region: "region"},
+		parameterstore.ParameterPath{Name: "/Wall", Region: "region"},
+		parameterstore.CopyOptions{SkipTags: true},
+	)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var copied []string
+	for _, put := range calls.PutParameter {
+		copied = append(copied, aws.StringValue(put.Name))
+	}
+	if !equal(copied, []string{"/Wall/Lannister/JaimeLannister", "/Wall/Stark/JonSnow"}) {
+		t.Fatalf("unexpected copies %v", copied)
+	}
+}
+
+func TestFind(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	now := time.Now()
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/House": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/House/Stark/JonSnow"), LastModifiedDate: aws.Time(now.Add(-time.Hour)), LastModifiedUser: aws.String("arn:aws:iam::123456789012:user/sam")},
+					{Name: aws.String("/House/Stark/EddardStark"), LastModifiedDate: aws.Time(now.AddDate(0, 0, -30)), LastModifiedUser: aws.String("arn:aws:iam::123456789012:user/maester")},
+					{Name: aws.String("/House/Lannister/JaimeLannister"), LastModifiedDate: aws.Time(now.Add(-time.Minute)), LastModifiedUser: aws.String("arn:aws:iam::123456789012:user/maester")},
+				},
+			},
+		},
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/House": {
+				Parameters: []*ssm.Parameter{{Name: aws.String("/House/Stark/JonSnow")}},
+			},
+		},
+		Calls: calls,
+	}
+	path := parameterstore.ParameterPath{Name: "/House", Region: "region"}
+
+	cases := []struct {
+		Options  parameterstore.FindOptions
+		Expected []string
+	}{
+		{parameterstore.FindOptions{}, []string{"/House/Lannister/JaimeLannister", "/House/Stark/EddardStark", "/House/Stark/JonSnow"}},
+		{parameterstore.FindOptions{Name: "*Stark"}, []string{"/House/Stark/EddardStark"}},
+		{parameterstore.FindOptions{ModifiedSince: now.Add(-24 * time.Hour)}, []string{"/House/Lannister/JaimeLannister", "/House/Stark/JonSnow"}},
+		{parameterstore.FindOptions{ModifiedBy: "maester"}, []string{"/House/Lannister/JaimeLannister", "/House/Stark/EddardStark"}},
+		{parameterstore.FindOptions{Label: "nights-watch"}, []string{"/House/Stark/JonSnow"}},
+	}
+	for _, c := range cases {
+		found, err := p.Find(path, c.Options)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		var names []string
+		for _, m := range found {
+			names = append(names, aws.StringValue(m.Name))
+		}
+		if !equal(names, c.Expected) {
+			t.Fatalf("expected %v for %+v, got %v", c.Expected, c.Options, names)
+		}
+	}
+
+	calls.DescribeParameters = nil
+	_, err = p.Find(path, parameterstore.FindOptions{
+		Type:  "SecureString",
+		KeyID: "alias/aws/ssm",
+		Tags:  []ssm.Tag{{Key: aws.String("House"), Value: aws.String("Stark")}},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var filters []string
+	for _, f := range calls.DescribeParameters[0].ParameterFilters {
+		filters = append(filters, aws.StringValue(f.Key)+"="+strings.Join(aws.StringValueSlice(f.Values), ","))
+	}
+	if !equal(filters, []string{"Path=/House", "Type=SecureString", "KeyId=alias/aws/ssm", "tag:House=Stark"}) {
+		t.Fatalf("unexpected filters %v", filters)
+	}
+}
+
+func TestGrep(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	raven := &ssm.Parameter{Name: aws.String("/Castle/Black/raven"), Type: aws.String("String"), Value: aws.String("winterfell.example.com")}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterResp: []ssm.GetParameterOutput{{Parameter: raven}},
+		GetParametersByPathResps: map[string]ssm.GetParametersByPathOutput{
+			"/House/Stark": {
+				Parameters: []*ssm.Parameter{
+					{Name: aws.String("/House/Stark/seat"), Type: aws.String("String"), Value: aws.String("Winterfell")},
+					{Name: aws.String("/House/Stark/words"), Type: aws.String("String"), Value: aws.String("Winter is coming")},
+					{Name: aws.String("/House/Stark/secret"), Type: aws.String("SecureString"), Value: aws.String("winterfell crypt")},
+				},
+			},
+		},
+	}
+	params := []parameterstore.ParameterPath{
+		{Name: "/House/Stark", Region: "region"},
+		{Name: "/Castle/Black/raven", Region: "region"},
+	}
+	cases := []struct {
+		Pattern  string
+		Decrypt  bool
+		Expected []string
+	}{
+		{"Winterfell", false, []string{"/House/Stark/seat"}},
+		{"(?i)winterfell", false, []string{"/House/Stark/seat", "/Castle/Black/raven"}},
+		{"(?i)winterfell", true, []string{"/House/Stark/seat", "/House/Stark/secret", "/Castle/Black/raven"}},
+		{"^Winter ", false, []string{"/House/Stark/words"}},
+	}
+	for _, c := range cases {
+		p.Decrypt = c.Decrypt
+		matches, err := p.Grep(regexp.MustCompile(c.Pattern), params, true)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		var names []string
+		for _, m := range matches {
+			names = append(names, aws.StringValue(m.Name))
+		}
+		if !equal(names, c.Expected) {
+			t.Fatalf("expected %v for %s, got %v", c.Expected, c.Pattern, names)
+		}
+	}
+
+	_, err = p.Grep(regexp.MustCompile("Winter"), []parameterstore.ParameterPath{{Name: "/House/Greyjoy", Region: "region"}}, true)
+	if err == nil {
+		t.Fatal("expected error for a missing path")
+	}
+}
+
+func TestTree(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.Clients[p.Region] = mockedSSM{
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/House": {
+				Parameters: []*ssm.ParameterMetadata{
+					{Name: aws.String("/House/Stark/JonSnow"), Type: aws.String("String")},
+					{Name: aws.String("/House/Stark"), Type: aws.String("String")},
+					{Name: aws.String("/House/Lannister/Casterly/TywinLannister"), Type: aws.String("String")},
+					{Name: aws.String("/House/Stark/AryaStark"), Type: aws.String("String")},
+				},
+			},
+		},
+	}
+	root, err := p.Tree(parameterstore.ParameterPath{Name: "/House", Region: "region"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var render func(n *parameterstore.TreeNode, indent string) []string
+	render = func(n *parameterstore.TreeNode, indent string) (lines []string) {
+		for _, c := range n.SortedChildren() {
+			line := indent + c.Name
+			if c.Parameter != nil {
+				line += "*"
+			}
+			lines = append(lines, line)
+			lines = append(lines, render(c, indent+" ")...)
+		}
+		return lines
+	}
+	expected := []string{"Lannister", " Casterly", "  TywinLannister*", "Stark*", " AryaStark*", " JonSnow*"}
+	if got := render(root, ""); !equal(got, expected) {
+		t.Fatalf("expected tree %v, got %v", expected, got)
+	}
+
+	_, err = p.Tree(parameterstore.ParameterPath{Name: "/Castle", Region: "region"})
+	var nferr *parameterstore.NotFoundError
+	if !errors.As(err, &nferr) {
+		t.Fatalf("expected a not found error for an empty path, got %v", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var metadata []*ssm.ParameterMetadata
+	var params []parameterstore.ParameterPath
+	for i := 0; i < 60; i++ {
+		name := fmt.Sprintf("/House/Stark/Bannerman%d", i)
+		metadata = append(metadata, &ssm.ParameterMetadata{Name: aws.String(name), Version: aws.Int64(int64(i))})
+		params = append(params, parameterstore.ParameterPath{Name: name, Region: "region"})
+	}
+	params = append(params, parameterstore.ParameterPath{Name: "/House/Stark/Hodor", Region: "region"})
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		Calls: calls,
+		DescribeParametersResps: map[string]ssm.DescribeParametersOutput{
+			"/House/Stark": {Parameters: metadata},
+		},
+	}
+	p.Cwd = "/House/Stark"
+	params[0].Name = "Bannerman0"
+	described, err := p.Describe(params)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.DescribeParameters) != 2 {
+		t.Fatalf("expected 2 DescribeParameters calls, got %d", len(calls.DescribeParameters))
+	}
+	if len(described) != 60 {
+		t.Fatalf("expected metadata for 60 parameters, got %d", len(described))
+	}
+	if m, ok := described["/House/Stark/Bannerman0"]; !ok || aws.Int64Value(m.Version) != 0 {
+		t.Fatal("expected metadata for a relative name")
+	}
+	if _, ok := described["/House/Stark/Hodor"]; ok {
+		t.Fatal("expected no metadata for a missing parameter")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	param := parameterstore.ParameterPath{
+		Name:   "/House/Stark/SansaStark",
+		Region: "region",
+	}
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterHistoryResp: ssm.GetParameterHistoryOutput{
+			Parameters: []*ssm.ParameterHistory{
+				{
+					Name:    aws.String(param.Name),
+					Value:   aws.String("Lady of Winterfell"),
+					Type:    aws.String("SecureString"),
+					KeyId:   aws.String("alias/winterfell"),
+					Tier:    aws.String(ssm.ParameterTierAdvanced),
+					Version: aws.Int64(1),
+				},
+			},
+		},
+		PutParameterResp: ssm.PutParameterOutput{Version: aws.Int64(2)},
+		Calls:            calls,
+	}
+
+	_, err = p.EditTarget(parameterstore.ParameterPath{Name: param.Name + ":1", Region: "region"})
+	if err == nil {
+		t.Fatal("expected error editing a previous version")
+	}
+	current, err := p.EditTarget(param)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if p.Decrypt {
+		t.Fatal("expected decryption setting to be restored")
+	}
+	_, err = p.Edit(param, current, "Lady of Winterfell")
+	if err == nil {
+		t.Fatal("expected error for an unchanged value")
+	}
+	resp, err := p.Edit(param, current, "Queen in the North")
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.Int64Value(resp.Version) != 2 {
+		t.Fatalf("expected version 2, got %d", aws.Int64Value(resp.Version))
+	}
+	if len(calls.PutParameter) != 1 {
+		t.Fatalf("expected 1 put, got %d", len(calls.PutParameter))
+	}
+	in := calls.PutParameter[0]
+	if aws.StringValue(in.Value) != "Queen in the North" ||
+		aws.StringValue(in.Type) != "SecureString" ||
+		aws.StringValue(in.KeyId) != "alias/winterfell" ||
+		aws.StringValue(in.Tier) != ssm.ParameterTierAdvanced ||
+		!aws.BoolValue(in.Overwrite) {
+		t.Fatalf("expected the type, key and tier to be preserved with overwrite, got %+v", in)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterResp: []ssm.GetParameterOutput{
+			{Parameter: &ssm.Parameter{Name: aws.String("/House/Greyjoy/TheonGreyjoy")}},
+		},
+		Calls: calls,
+	}
+	p.DryRun = true
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:      aws.String("/House/Greyjoy/TheonGreyjoy"),
+		Value:     aws.String("Reek"),
+		Type:      aws.String("String"),
+		Overwrite: aws.Bool(true),
+		Tags:      []*ssm.Tag{{Key: aws.String("Status"), Value: aws.String("Captive")}},
+	}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	err = p.Remove([]parameterstore.ParameterPath{{Name: "/House/Greyjoy/TheonGreyjoy", Region: "region"}}, false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.PutParameter) != 0 || len(calls.AddTagsToResource) != 0 || len(calls.DeleteParameters) != 0 {
+		t.Fatal("expected no modifying calls in dry run mode")
+	}
+	var operations []string
+	for _, call := range p.TakePlan() {
+		operations = append(operations, call.Operation)
+		if call.Client != "region" {
+			t.Fatalf("expected client region, got %s", call.Client)
+		}
+	}
+	expected := []string{"PutParameter", "AddTagsToResource", "DeleteParameters"}
+	if !equal(operations, expected) {
+		t.Fatalf("expected plan %v, got %v", expected, operations)
+	}
+	if len(p.TakePlan()) != 0 {
+		t.Fatal("expected the plan to be cleared")
+	}
+
+	p.DryRun = false
+	err = p.Remove([]parameterstore.ParameterPath{{Name: "/House/Greyjoy/TheonGreyjoy", Region: "region"}}, false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.DeleteParameters) != 1 {
+		t.Fatal("expected a delete with dry run mode off")
+	}
+}
+
+func TestResolveAndDelete(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterResp: []ssm.GetParameterOutput{
+			{Parameter: &ssm.Parameter{Name: aws.String("/House/Tully/CatelynStark")}},
+		},
+		Calls: calls,
+	}
+	p.Cwd = "/House/Tully"
+	if !p.Exists(parameterstore.ParameterPath{Name: "CatelynStark", Region: "region"}) {
+		t.Fatal("expected a relative parameter to exist")
+	}
+	resolved, err := p.Resolve([]parameterstore.ParameterPath{{Name: "CatelynStark", Region: "region"}}, false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(resolved) != 1 || resolved[0].Name != "/House/Tully/CatelynStark" {
+		t.Fatalf("expected /House/Tully/CatelynStark, got %v", resolved)
+	}
+	err = p.Delete(resolved)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(calls.DeleteParameters) != 1 || aws.StringValue(calls.DeleteParameters[0].Names[0]) != "/House/Tully/CatelynStark" {
+		t.Fatal("expected the resolved parameter to be deleted")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterResp: []ssm.GetParameterOutput{
+			{Parameter: &ssm.Parameter{Name: aws.String("/House/Baratheon/RobertBaratheon")}},
+		},
+		Calls: calls,
+	}
+	p.ReadOnly = true
+	param := parameterstore.ParameterPath{Name: "/House/Baratheon/RobertBaratheon", Region: "region"}
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:  aws.String(param.Name),
+		Value: aws.String("King"),
+		Type:  aws.String("String"),
+	}, p.Region)
+	if err != parameterstore.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly from Put, got %v", err)
+	}
+	err = p.Remove([]parameterstore.ParameterPath{param}, false)
+	if err != parameterstore.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly from Remove, got %v", err)
+	}
+	err = p.AddTags([]parameterstore.ParameterPath{param}, []*ssm.Tag{{Key: aws.String("Status"), Value: aws.String("Deceased")}}, false)
+	if err != parameterstore.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly from AddTags, got %v", err)
+	}
+	err = p.LabelParameterVersion(param, []string{"season1"})
+	if err != parameterstore.ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly from LabelParameterVersion, got %v", err)
+	}
+	if len(calls.PutParameter) != 0 || len(calls.DeleteParameters) != 0 ||
+		len(calls.AddTagsToResource) != 0 || len(calls.LabelParameterVersion) != 0 {
+		t.Fatal("expected no modifying calls in read-only mode")
+	}
+	if !p.Exists(param) {
+		t.Fatal("expected reads to be allowed in read-only mode")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	bran := "/House/Stark/BranStark"
+	rickon := "/House/Stark/RickonStark"
+	calls := &mockCalls{}
+	client := mockedSSM{
+		GetParameterHistoryResps: map[string]ssm.GetParameterHistoryOutput{
+			bran: {
+				Parameters: []*ssm.ParameterHistory{
+					{Name: aws.String(bran), Value: aws.String("Boy"), Type: aws.String("String"), Version: aws.Int64(1)},
+				},
+			},
+		},
+		DeleteParametersResp: ssm.DeleteParametersOutput{DeletedParameters: aws.StringSlice([]string{bran})},
+		ListTagsForResourceResp: ssm.ListTagsForResourceOutput{
+			TagList: []*ssm.Tag{{Key: aws.String("Status"), Value: aws.String("Warg")}},
+		},
+		Calls: calls,
+	}
+	p.Clients[p.Region] = client
+	// Undo creates the client for the region of each change again
+	p.NewClient = func(region, profile string) ssmiface.SSMAPI { return client }
+
+	p.BeginOperation("put " + bran)
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:      aws.String(bran),
+		Value:     aws.String("Three-Eyed Raven"),
+		Type:      aws.String("String"),
+		Overwrite: aws.Bool(true),
+	}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.EndOperation()
+	_, err = p.Put(&ssm.PutParameterInput{Name: aws.String(rickon), Value: aws.String("Boy"), Type: aws.String("String")}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	err = p.Delete([]parameterstore.ParameterPath{{Name: bran, Region: "region"}})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	journal := p.Journal()
+	if len(journal) != 3 {
+		t.Fatalf("expected 3 operations in the journal, got %d", len(journal))
+	}
+	if c := journal[1].Changes[0]; c.Existed || c.Name != rickon {
+		t.Fatalf("expected %s not to have existed, got %+v", rickon, c)
+	}
+
+	_, err = p.Undo(4)
+	if err == nil {
+		t.Fatal("expected error undoing more operations than the journal has")
+	}
+	undone, err := p.Undo(2)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(undone) != 2 || len(p.Journal()) != 1 {
+		t.Fatalf("expected 2 operations undone and 1 remaining, got %d and %d", len(undone), len(p.Journal()))
+	}
+	restored := calls.PutParameter[2]
+	if aws.StringValue(restored.Name) != bran || aws.StringValue(restored.Value) != "Boy" ||
+		len(restored.Tags) != 1 || aws.BoolValue(restored.Overwrite) {
+		t.Fatalf("expected %s to be restored with its tags, got %+v", bran, restored)
+	}
+	if len(calls.DeleteParameters) != 2 || aws.StringValue(calls.DeleteParameters[1].Names[0]) != rickon {
+		t.Fatalf("expected %s to be deleted", rickon)
+	}
+
+	_, err = p.Undo(1)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	reverted := calls.PutParameter[3]
+	if aws.StringValue(reverted.Value) != "Boy" || !aws.BoolValue(reverted.Overwrite) {
+		t.Fatalf("expected %s to be overwritten with its previous value, got %+v", bran, reverted)
+	}
+	if len(p.Journal()) != 0 {
+		t.Fatal("expected an empty journal")
+	}
+}
+
+func TestUndoAfterSwitch(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "us-east-1"
+	p.Profile = "default"
+	regions := fake.NewRegions()
+	var profiles []string
+	p.NewClient = func(region, profile string) ssmiface.SSMAPI {
+		profiles = append(profiles, profile)
+		return regions.Client(region, profile)
+	}
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	name := "/House/Greyjoy/TheonGreyjoy"
+	_, err = p.Put(&ssm.PutParameterInput{Name: aws.String(name), Value: aws.String("Prince"), Type: aws.String("String")}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	p.Region = "us-west-2"
+	err = p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	err = p.SetProfile("other")
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	profiles = nil
+	_, err = p.Undo(1)
+	if err != nil {
+		t.Fatal("unexpected error undoing after switching region and profile", err)
+	}
+	if !equal(profiles, []string{"default"}) {
+		t.Fatalf("expected a client for the profile of the change, got clients for %v", profiles)
+	}
+	_, err = regions.Store("us-east-1").GetParameter(&ssm.GetParameterInput{Name: aws.String(name)})
+	if err == nil {
+		t.Fatalf("expected %s to be deleted in the region it was created in", name)
+	}
+}
+
+func TestUndoUnknownState(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	calls := &mockCalls{}
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterHistoryErr: errors.New("AccessDeniedException"),
+		Calls:                  calls,
+	}
+	name := "/House/Stark/SansaStark"
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String("Queen in the North"),
+		Type:      aws.String("SecureString"),
+		Overwrite: aws.Bool(true),
+	}, p.Region)
+	if err != nil {
+		t.Fatal("expected the put to succeed without reading the history, got", err)
+	}
+	if len(calls.PutParameter) != 1 {
+		t.Fatalf("expected 1 put, got %d", len(calls.PutParameter))
+	}
+	journal := p.Journal()
+	if len(journal) != 1 || !journal[0].Changes[0].Unknown {
+		t.Fatalf("expected a change with an unknown prior state, got %+v", journal)
+	}
+	_, err = p.Undo(1)
+	if err == nil {
+		t.Fatal("expected an error undoing a change with an unknown prior state")
+	}
+	if len(p.Journal()) != 1 || len(calls.PutParameter) != 1 {
+		t.Fatal("expected the operation to remain in the journal unchanged")
+	}
+}
+
+func TestUndoUpgradeAndTags(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	regions := fake.NewRegions()
+	p.NewClient = regions.Client
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	name := "/House/Tarly/SamwellTarly"
+	_, err = regions.Store("region").PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String(name),
+		Value: aws.String("Steward"),
+		Type:  aws.String("String"),
+		Tier:  aws.String(ssm.ParameterTierStandard),
+		Tags:  []*ssm.Tag{{Key: aws.String("Order"), Value: aws.String("Night's Watch")}},
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String("Maester"),
+		Type:      aws.String("String"),
+		Tier:      aws.String(ssm.ParameterTierAdvanced),
+		Overwrite: aws.Bool(true),
+		Tags: []*ssm.Tag{
+			{Key: aws.String("Order"), Value: aws.String("Citadel")},
+			{Key: aws.String("Chain"), Value: aws.String("Valyrian steel")},
+		},
+	}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = p.Undo(1)
+	if err != nil {
+		t.Fatal("unexpected error undoing an upgrade to the advanced tier", err)
+	}
+
+	history, err := p.GetHistory(parameterstore.ParameterPath{Name: name, Region: "region"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	latest := history[len(history)-1]
+	if aws.StringValue(latest.Value) != "Steward" || aws.StringValue(latest.Tier) != ssm.ParameterTierAdvanced {
+		t.Fatalf("expected the previous value in the advanced tier, got %+v", latest)
+	}
+	tags, err := p.ListTags([]parameterstore.ParameterPath{{Name: name, Region: "region"}}, false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(tags) != 1 || len(tags[0].Tags) != 1 || aws.StringValue(tags[0].Tags[0].Value) != "Night's Watch" {
+		t.Fatalf("expected the previous tags, got %+v", tags)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.Profile = "default"
+	p.Session.CABundle = filepath.Join(t.TempDir(), "missing.pem")
+	err := p.NewParameterStore(false)
+	if err == nil {
+		t.Fatal("expected an error creating a client with a missing CA bundle")
+	}
+	err = p.SetProfile("other")
+	if err == nil || p.Profile != "default" {
+		t.Fatalf("expected an error and the profile to be unchanged, got %v and %s", err, p.Profile)
+	}
+	_, err = p.KMSClient(parameterstore.ParameterPath{Region: "region"})
+	if err == nil {
+		t.Fatal("expected an error creating a KMS client with a missing CA bundle")
+	}
+}
+
+func TestRestoreOntoAdvanced(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	regions := fake.NewRegions()
+	p.NewClient = regions.Client
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	name := "/House/Mormont/JorahMormont"
+	_, err = regions.Store("region").PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String(name),
+		Value: aws.String("Knight"),
+		Type:  aws.String("String"),
+		Tier:  aws.String(ssm.ParameterTierAdvanced),
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	b := &parameterstore.Backup{
+		Path: "/House/Mormont",
+		Parameters: []parameterstore.BackupParameter{{
+			Name: "JorahMormont",
+			History: []ssm.ParameterHistory{
+				{Version: aws.Int64(1), Value: aws.String("Exile"), Type: aws.String("String"), Tier: aws.String(ssm.ParameterTierStandard)},
+				{Version: aws.Int64(2), Value: aws.String("Friendzoned"), Type: aws.String("String"), Tier: aws.String(ssm.ParameterTierStandard)},
+			},
+		}},
+	}
+	to := parameterstore.ParameterPath{Name: "/House/Mormont", Region: "region"}
+	_, err = p.Restore(b, to, false)
+	if err == nil {
+		t.Fatal("expected an error restoring over an existing parameter without overwrite")
+	}
+	versions, err := p.Restore(b, to, true)
+	if err != nil {
+		t.Fatal("unexpected error restoring standard versions onto an advanced parameter", err)
+	}
+	if versions != 1 {
+		t.Fatalf("expected only the latest version to be restored, got %d", versions)
+	}
+	history, err := p.GetHistory(parameterstore.ParameterPath{Name: name, Region: "region"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(history))
+	}
+	latest := history[len(history)-1]
+	if aws.StringValue(latest.Value) != "Friendzoned" || aws.StringValue(latest.Tier) != ssm.ParameterTierAdvanced {
+		t.Fatalf("expected the latest version in the advanced tier, got %+v", latest)
+	}
+}
+
+func TestKMSClientProfileSwitch(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.Profile = "default"
+	p.NewClient = fake.NewRegions().Client
+	var profiles []string
+	p.NewKMSClient = func(region, profile string) kmsiface.KMSAPI {
+		profiles = append(profiles, profile)
+		return fake.NewKMS(region)
+	}
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	for _, profile := range []string{"default", "other"} {
+		if profile != p.Profile {
+			err = p.SetProfile(profile)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		_, err = p.KMSClient(parameterstore.ParameterPath{Region: "region"})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	if !equal(profiles, []string{"default", "other"}) {
+		t.Fatalf("expected a KMS client for each profile, got clients for %v", profiles)
+	}
+}
+
+type mockedSTS struct {
+	stsiface.STSAPI
+	Arn string
+}
+
+func (m mockedSTS) GetCallerIdentity(in *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Arn: aws.String(m.Arn)}, nil
+}
+
+func TestAuditLog(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.Profile = "default"
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	name := "/House/Tarly/SamwellTarly"
+	p.Clients[p.Region] = mockedSSM{
+		GetParameterResp: []ssm.GetParameterOutput{
+			{Parameter: &ssm.Parameter{Name: aws.String(name), Version: aws.Int64(3)}},
+		},
+		PutParameterResp:     ssm.PutParameterOutput{Version: aws.Int64(3)},
+		DeleteParametersResp: ssm.DeleteParametersOutput{DeletedParameters: aws.StringSlice([]string{name})},
+	}
+	p.STSClients = map[string]stsiface.STSAPI{
+		"region": mockedSTS{Arn: "arn:aws:iam::012345678901:user/sam"},
+	}
+	var log bytes.Buffer
+	p.AuditLog = &log
+
+	p.BeginOperation("put name=" + name + " value=********")
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String("Maester"),
+		Type:      aws.String("SecureString"),
+		Overwrite: aws.Bool(true),
+	}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	p.EndOperation()
+	err = p.Delete([]parameterstore.ParameterPath{{Name: name, Region: "region"}})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	if strings.Contains(log.String(), "Maester") {
+		t.Fatal("expected the audit log not to contain parameter values")
+	}
+	var entries []parameterstore.AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var entry parameterstore.AuditEntry
+		err = json.Unmarshal([]byte(line), &entry)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	put := entries[0]
+	if put.Action != "PutParameter" || put.Name != name || put.OldVersion != 2 || put.NewVersion != 3 ||
+		put.Identity != "arn:aws:iam::012345678901:user/sam" || put.Profile != "default" || put.Region != "region" ||
+		!strings.HasPrefix(put.Command, "put ") {
+		t.Fatalf("unexpected put entry %+v", put)
+	}
+	del := entries[1]
+	if del.Action != "DeleteParameters" || del.Name != name || del.OldVersion != 3 || del.Command != "" {
+		t.Fatalf("unexpected delete entry %+v", del)
+	}
+}
+
+func TestMoveWithFakeBackend(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.NewClient = fake.NewRegions().Client
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	for i := 0; i < 15; i++ {
+		_, err = p.Put(&ssm.PutParameterInput{
+			Name:  aws.String(fmt.Sprintf("/House/Mormont/Bear%02d", i)),
+			Value: aws.String("Here we stand"),
+			Type:  aws.String("SecureString"),
+			Tags:  []*ssm.Tag{{Key: aws.String("Island"), Value: aws.String("Bear")}},
+		}, p.Region)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	err = p.Move(parameterstore.ParameterPath{Name: "/House/Mormont", Region: "region"},
+		parameterstore.ParameterPath{Name: "/NightsWatch/Mormont", Region: "region"}, parameterstore.CopyOptions{})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	moved, err := p.Resolve([]parameterstore.ParameterPath{{Name: "/NightsWatch", Region: "region"}}, true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(moved) != 15 {
+		t.Fatalf("expected 15 moved parameters, got %d", len(moved))
+	}
+	if p.Exists(parameterstore.ParameterPath{Name: "/House/Mormont", Region: "region"}) {
+		t.Fatal("expected the source path to be removed")
+	}
+	tags, err := p.ListTags(moved[:1], false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(tags[0].Tags) != 1 {
+		t.Fatalf("expected tags to be moved, got %+v", tags)
+	}
+	p.Decrypt = true
+	history, err := p.GetHistory(moved[0])
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if aws.StringValue(history[0].Value) != "Here we stand" {
+		t.Fatalf("expected the decrypted value to be moved, got %s", aws.StringValue(history[0].Value))
+	}
+}
+
+func TestAuditAndBackupWithFakeBackend(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	regions := fake.NewRegions()
+	p.NewClient = regions.Client
+	p.NewKMSClient = regions.KMSClient
+	p.NewSTSClient = regions.STSClient
+	err := p.NewParameterStore(true)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var log bytes.Buffer
+	p.AuditLog = &log
+
+	_, err = p.Put(&ssm.PutParameterInput{
+		Name:  aws.String("/House/Reed/HowlandReed"),
+		Value: aws.String("Greywater Watch"),
+		Type:  aws.String("SecureString"),
+	}, p.Region)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var entry parameterstore.AuditEntry
+	err = json.Unmarshal(log.Bytes(), &entry)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if entry.Identity != fake.DefaultUser {
+		t.Fatalf("expected the fake identity in the audit log, got %+v", entry)
+	}
+
+	b, err := p.Backup(parameterstore.ParameterPath{Name: "/House/Reed", Region: "region"})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var buf bytes.Buffer
+	err = p.WriteBackup(&buf, b, parameterstore.BackupKey{KMSKeyID: fake.DefaultKMSKeyID, KMSPath: parameterstore.ParameterPath{Region: "region"}})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	read, err := p.ReadBackup(&buf, nil)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if len(read.Parameters) != 1 || aws.StringValue(read.Parameters[0].History[0].Value) != "Greywater Watch" {
+		t.Fatalf("unexpected backup %+v", read)
+	}
+
+	err = p.WriteBackup(&buf, b, parameterstore.BackupKey{KMSKeyID: "alias/missing", KMSPath: parameterstore.ParameterPath{Region: "region"}})
+	if err == nil {
+		t.Fatal("expected an error for a key the fake does not have")
+	}
+}
+
+func TestProtected(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.NewClient = fake.NewRegions().Client
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	for _, name := range []string{"/House/Stark/AryaStark", "/House/Starkey/Ned"} {
+		_, err = p.Put(&ssm.PutParameterInput{Name: aws.String(name), Value: aws.String("Winterfell"), Type: aws.String("String")}, p.Region)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	p.Protected = []string{"/House/Stark/"}
+
+	var perr *parameterstore.ProtectedError
+	for _, dryRun := range []bool{false, true} {
+		p.DryRun = dryRun
+		err = p.Delete([]parameterstore.ParameterPath{{Name: "/House/Stark/AryaStark", Region: "region"}})
+		if !errors.As(err, &perr) || perr.Prefix != "/House/Stark/" {
+			t.Fatalf("expected a protected error in dry run mode %t, got %v", dryRun, err)
+		}
+		err = p.AddTags([]parameterstore.ParameterPath{{Name: "/House/Stark/AryaStark", Region: "region"}}, []*ssm.Tag{{Key: aws.String("Sigil"), Value: aws.String("Wolf")}}, false)
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected a protected error in dry run mode %t, got %v", dryRun, err)
+		}
+	}
+	p.DryRun = false
+	if plan := p.TakePlan(); len(plan) != 0 {
+		t.Fatalf("expected refused calls not to be planned, got %+v", plan)
+	}
+	if !p.Exists(parameterstore.ParameterPath{Name: "/House/Stark/AryaStark", Region: "region"}) {
+		t.Fatal("expected the protected parameter to remain")
+	}
+	// Names that only share a prefix are not protected
+	err = p.Delete([]parameterstore.ParameterPath{{Name: "/House/Starkey/Ned", Region: "region"}})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestAuditLogProfileSwitch(t *testing.T) {
+	var p parameterstore.ParameterStore
+	p.Region = "region"
+	p.Profile = "default"
+	p.NewClient = fake.NewRegions().Client
+	p.NewSTSClient = func(region, profile string) stsiface.STSAPI {
+		return mockedSTS{Arn: "arn:aws:iam::012345678901:user/" + profile}
+	}
+	err := p.NewParameterStore(false)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	var log bytes.Buffer
+	p.AuditLog = &log
+
+	for _, profile := range []string{"default", "other"} {
+		if profile != p.Profile {
+			err = p.SetProfile(profile)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		_, err = p.Put(&ssm.PutParameterInput{
+			Name:  aws.String("/House/Baratheon/" + profile),
+			Value: aws.String("Ours is the fury"),
+			Type:  aws.String("String"),
+		}, p.Region)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(lines))
+	}
+	for i, profile := range []string{"default", "other"} {
+		var entry parameterstore.AuditEntry
+		err = json.Unmarshal([]byte(lines[i]), &entry)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if entry.Profile != profile || entry.Identity != "arn:aws:iam::012345678901:user/"+profile {
+			t.Fatalf("expected the identity of profile %s, got %+v", profile, entry)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package parameterstore
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// IsBeneath reports whether a name is a path prefix or beneath it, e.g. /prod/app is beneath /prod but /production is not
+func IsBeneath(name, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, Delimiter)
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+Delimiter)
+}
+
+// CheckProtected returns a ProtectedError if any of the names is beneath a protected path
+func (ps *ParameterStore) CheckProtected(names ...string) error {
+	for _, prefix := range ps.Protected {
+		for _, name := range names {
+			if IsBeneath(name, prefix) {
+				return &ProtectedError{Prefix: prefix}
+			}
+		}
+	}
+	return nil
+}
+
+// protector refuses calls that would modify parameters beneath protected paths and passes
+// every other call through to the client
+type protector struct {
+	ssmiface.SSMAPI
+	ps *ParameterStore
+}
+
+func (p protector) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValue(in.Name)); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.PutParameter(in)
+}
+
+func (p protector) DeleteParameter(in *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValue(in.Name)); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.DeleteParameter(in)
+}
+
+func (p protector) DeleteParameters(in *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValueSlice(in.Names)...); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.DeleteParameters(in)
+}
+
+func (p protector) AddTagsToResource(in *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValue(in.ResourceId)); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.AddTagsToResource(in)
+}
+
+func (p protector) RemoveTagsFromResource(in *ssm.RemoveTagsFromResourceInput) (*ssm.RemoveTagsFromResourceOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValue(in.ResourceId)); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.RemoveTagsFromResource(in)
+}
+
+func (p protector) LabelParameterVersion(in *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValue(in.Name)); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.LabelParameterVersion(in)
+}
+
+func (p protector) UnlabelParameterVersion(in *ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	if err := p.ps.CheckProtected(aws.StringValue(in.Name)); err != nil {
+		return nil, err
+	}
+	return p.SSMAPI.UnlabelParameterVersion(in)
+}
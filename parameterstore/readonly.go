@@ -0,0 +1,44 @@
+package parameterstore
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// ErrReadOnly is returned for calls that would modify parameters in read-only mode
+var ErrReadOnly = errors.New("read-only mode, parameters cannot be changed")
+
+// readOnlyClient refuses calls that would modify parameters and passes every other call through to the client
+type readOnlyClient struct {
+	ssmiface.SSMAPI
+}
+
+func (readOnlyClient) PutParameter(*ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyClient) DeleteParameter(*ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyClient) DeleteParameters(*ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyClient) AddTagsToResource(*ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyClient) RemoveTagsFromResource(*ssm.RemoveTagsFromResourceInput) (*ssm.RemoveTagsFromResourceOutput, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyClient) LabelParameterVersion(*ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyClient) UnlabelParameterVersion(*ssm.UnlabelParameterVersionInput) (*ssm.UnlabelParameterVersionOutput, error) {
+	return nil, ErrReadOnly
+}
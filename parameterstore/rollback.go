@@ -0,0 +1,50 @@
+package parameterstore
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// RollbackTarget returns the latest version of a parameter and the version to roll back to.
+// The target is selected with a name:version or name:label suffix, otherwise it is the
+// version prior to the latest. Values are always decrypted.
+func (ps *ParameterStore) RollbackTarget(param ParameterPath) (latest, target ssm.ParameterHistory, err error) {
+	name, selector := splitSelector(fqp(param.Name, ps.Cwd))
+	param.Name = name
+	history, err := ps.history(param, true)
+	if err != nil {
+		return latest, target, err
+	}
+	if len(history) == 0 {
+		return latest, target, notFound("no history found for %s", name)
+	}
+	latest = history[len(history)-1]
+	if selector == "" {
+		if len(history) < 2 {
+			return latest, target, fmt.Errorf("%s has no previous version", name)
+		}
+		target = history[len(history)-2]
+	} else {
+		var ok bool
+		target, ok = selectHistory(history, selector)
+		if !ok {
+			return latest, target, fmt.Errorf("no version of %s matches %s", name, selector)
+		}
+	}
+	if aws.Int64Value(target.Version) == aws.Int64Value(latest.Version) {
+		return latest, target, fmt.Errorf("version %d is already the latest version of %s", aws.Int64Value(target.Version), name)
+	}
+	return latest, target, nil
+}
+
+// Rollback puts the value and settings of a previous version of a parameter as a new version
+func (ps *ParameterStore) Rollback(param ParameterPath, latest, target ssm.ParameterHistory) (*ssm.PutParameterOutput, error) {
+	name, _ := splitSelector(fqp(param.Name, ps.Cwd))
+	putParamInput := putInputFromHistory(target, CopyOptions{})
+	putParamInput.Tier = tierFor(aws.StringValue(latest.Tier), putParamInput.Tier)
+	putParamInput.Name = aws.String(name)
+	putParamInput.Overwrite = aws.Bool(true)
+	return ps.Put(putParamInput, ps.ClientKey(param))
+}
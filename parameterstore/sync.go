@@ -0,0 +1,108 @@
+package parameterstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sync actions
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncAction is a change needed to make a destination path mirror a source path
+type SyncAction struct {
+	Action  string // SyncCreate, SyncUpdate or SyncDelete
+	Src     ParameterPath
+	Dst     ParameterPath
+	Changes []string // The fields that differ, for updates
+}
+
+// SyncPlan returns the actions needed to make the parameters beneath dst mirror those beneath src.
+// Parameters that exist only in dst are deleted when del is true. Fields that the options leave
+// out of copies are not compared, and neither are KMS keys when dst uses another region or
+// profile, because keys belong to a region and account, and neither is the tier when tierFor
+// would leave it out. Tags are not compared.
+func (ps *ParameterStore) SyncPlan(src, dst ParameterPath, del bool, opts CopyOptions) (plan []SyncAction, err error) {
+	src.Name = fqp(src.Name, ps.Cwd)
+	dst.Name = fqp(dst.Name, ps.Cwd)
+	if !ps.isPath(src) {
+		return nil, fmt.Errorf("%s is not a path", src.Name)
+	}
+	diffs, err := ps.Diff(src, dst, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range diffs {
+		srcParam := src
+		srcParam.Name = absoluteName(src.Name, d.Name)
+		dstParam := dst
+		dstParam.Name = absoluteName(dst.Name, d.Name)
+		action := SyncAction{Src: srcParam, Dst: dstParam}
+		switch {
+		case d.Dst == nil:
+			action.Action = SyncCreate
+		case d.Src == nil:
+			if !del {
+				continue
+			}
+			action.Action = SyncDelete
+		default:
+			action.Action = SyncUpdate
+			for _, c := range d.Changes {
+				if !syncIgnores(c, opts, ps.ClientKey(src) != ps.ClientKey(dst)) {
+					action.Changes = append(action.Changes, c.Field)
+				}
+			}
+			if len(action.Changes) == 0 {
+				continue
+			}
+		}
+		plan = append(plan, action)
+	}
+	return plan, nil
+}
+
+// Sync applies the actions of a sync plan
+func (ps *ParameterStore) Sync(plan []SyncAction, opts CopyOptions) error {
+	if !ps.Overwrite {
+		// Overwrite required to update existing parameters
+		ps.Overwrite = true
+		defer func() {
+			ps.Overwrite = false
+		}()
+	}
+	var deletes []ParameterPath
+	for _, action := range plan {
+		switch action.Action {
+		case SyncCreate, SyncUpdate:
+			err := ps.copyParameter(action.Src, action.Dst, opts)
+			if err != nil {
+				return err
+			}
+		case SyncDelete:
+			deletes = append(deletes, action.Dst)
+		}
+	}
+	return ps.deleteByClient(deletes)
+}
+
+// syncIgnores reports whether a difference in a field is left alone by sync
+func syncIgnores(c FieldChange, opts CopyOptions, otherClient bool) bool {
+	switch c.Field {
+	case "Tier":
+		return opts.SkipTier || tierFor(c.Dst, &c.Src) == nil
+	case "Policies":
+		return opts.SkipTier || opts.SkipPolicies
+	case "KeyId":
+		return otherClient
+	}
+	return false
+}
+
+// absoluteName joins a path and a name relative to it
+func absoluteName(path, name string) string {
+	return strings.TrimSuffix(path, Delimiter) + Delimiter + name
+}
@@ -0,0 +1,90 @@
+package parameterstore
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ParameterTags holds the resource tags of a parameter
+type ParameterTags struct {
+	Name string
+	Tags []ssm.Tag
+}
+
+// AddTags adds tags to one or more parameters, replacing the values of existing keys
+func (ps *ParameterStore) AddTags(params []ParameterPath, tags []*ssm.Tag, recurse bool) error {
+	resolved, err := ps.resolveParameters(params, recurse)
+	if err != nil {
+		return err
+	}
+	for _, p := range resolved {
+		err = ps.addTags(p.Name, ps.ClientKey(p), tags)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveTags removes the tags with the given keys from one or more parameters
+func (ps *ParameterStore) RemoveTags(params []ParameterPath, keys []string, recurse bool) error {
+	resolved, err := ps.resolveParameters(params, recurse)
+	if err != nil {
+		return err
+	}
+	for _, p := range resolved {
+		input := &ssm.RemoveTagsFromResourceInput{
+			ResourceId:   aws.String(p.Name),
+			ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+			TagKeys:      aws.StringSlice(keys),
+		}
+		_, err = ps.client(p).RemoveTagsFromResource(input)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTags returns the tags of one or more parameters
+func (ps *ParameterStore) ListTags(params []ParameterPath, recurse bool) (r []ParameterTags, err error) {
+	resolved, err := ps.resolveParameters(params, recurse)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range resolved {
+		tags, err := ps.listTags(p.Name, ps.ClientKey(p))
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, ParameterTags{Name: p.Name, Tags: tags})
+	}
+	return r, nil
+}
+
+// addTags tags a single parameter with the client for a key from ClientKey
+func (ps *ParameterStore) addTags(name, key string, tags []*ssm.Tag) error {
+	input := &ssm.AddTagsToResourceInput{
+		ResourceId:   aws.String(name),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+		Tags:         tags,
+	}
+	_, err := ps.clientFor(key).AddTagsToResource(input)
+	return err
+}
+
+// listTags returns the tags of a single parameter with the client for a key from ClientKey
+func (ps *ParameterStore) listTags(name, key string) (tags []ssm.Tag, err error) {
+	input := &ssm.ListTagsForResourceInput{
+		ResourceId:   aws.String(name),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	}
+	resp, err := ps.clientFor(key).ListTagsForResource(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range resp.TagList {
+		tags = append(tags, *t)
+	}
+	return tags, nil
+}
@@ -0,0 +1,67 @@
+package parameterstore
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// TreeNode is a level of the parameter hierarchy. A node may be both a parameter and a path.
+type TreeNode struct {
+	Name      string                 // Name relative to the parent node
+	Parameter *ssm.ParameterMetadata // Set when the node is a parameter
+	Children  map[string]*TreeNode   // Keyed by name
+}
+
+// Tree returns the hierarchy beneath a path along with the metadata of each parameter
+func (ps *ParameterStore) Tree(path ParameterPath) (*TreeNode, error) {
+	path.Name = fqp(path.Name, ps.Cwd)
+	metadata, err := ps.describeParameters(path, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		return nil, notFound("No parameters found beneath %s", path.Name)
+	}
+	root := &TreeNode{Name: path.Name}
+	for _, m := range metadata {
+		node := root
+		for _, element := range strings.Split(relativeName(aws.StringValue(m.Name), path.Name), Delimiter) {
+			node = node.child(element)
+		}
+		node.Parameter = m
+	}
+	return root, nil
+}
+
+// IsPath reports whether a node has children
+func (n *TreeNode) IsPath() bool {
+	return len(n.Children) > 0
+}
+
+// child returns the child node with a name, adding it if needed
+func (n *TreeNode) child(name string) *TreeNode {
+	if c, ok := n.Children[name]; ok {
+		return c
+	}
+	if n.Children == nil {
+		n.Children = make(map[string]*TreeNode)
+	}
+	c := &TreeNode{Name: name}
+	n.Children[name] = c
+	return c
+}
+
+// SortedChildren returns the children of a node sorted by name
+func (n *TreeNode) SortedChildren() []*TreeNode {
+	children := make([]*TreeNode, 0, len(n.Children))
+	for _, c := range n.Children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"strings"
 
@@ -11,6 +11,7 @@ import (
 	"github.com/bwhaley/ssmsh/commands"
 	"github.com/bwhaley/ssmsh/config"
 	"github.com/bwhaley/ssmsh/parameterstore"
+	"github.com/bwhaley/ssmsh/parameterstore/fake"
 	"github.com/mattn/go-shellwords"
 )
 
@@ -19,6 +20,17 @@ var Version string
 func main() {
 	cfgFile := flag.String("config", "", "Load configuration from the specified file")
 	file := flag.String("file", "", "Read commands from file (use - for stdin)")
+	dryRun := flag.Bool("dry-run", false, "Print the changes commands would make instead of making them")
+	readOnly := flag.Bool("readonly", false, "Refuse commands that would change parameters")
+	backend := flag.String("backend", "aws", "Where parameters are stored: aws, or memory to run offline")
+	endpoint := flag.String("endpoint", "", "URL of the SSM API, e.g. a VPC endpoint, LocalStack or ssmsh-fake-server")
+	kmsEndpoint := flag.String("kms-endpoint", "", "URL of the KMS API")
+	stsEndpoint := flag.String("sts-endpoint", "", "URL of the STS API")
+	fips := flag.Bool("fips", false, "Use FIPS endpoints")
+	dualStack := flag.Bool("dualstack", false, "Use dual-stack (IPv4 and IPv6) endpoints")
+	caBundle := flag.String("ca-bundle", "", "Trust the certificate authorities in the specified PEM file")
+	maxRetries := flag.Int("max-retries", -1, "Retry failed requests at most this many times, or -1 for the AWS SDK default")
+	output := flag.String("output", "", "Format of results: "+strings.Join(commands.OutputFormats, ", "))
 	version := flag.Bool("version", false, "Display the current version")
 	flag.Parse()
 
@@ -29,17 +41,72 @@ func main() {
 
 	cfg, err := config.ReadConfig(*cfgFile)
 	if err != nil {
-		fmt.Printf("Error reading configuration file %s: %s\n", *cfgFile, err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error reading configuration file %s: %s\n", *cfgFile, err)
+		os.Exit(commands.ExitError)
+	}
+	if *output != "" {
+		cfg.Default.Output = *output
+	}
+	if cfg.Default.Output != "" && !commands.ValidOutput(cfg.Default.Output) {
+		fmt.Fprintf(os.Stderr, "Unknown output format %s, expected %s\n", cfg.Default.Output, strings.Join(commands.OutputFormats, ", "))
+		os.Exit(commands.ExitUsage)
 	}
 
 	shell := ishell.New()
 	var ps parameterstore.ParameterStore
 	ps.SetDefaults(cfg)
+	switch *backend {
+	case "aws":
+	case "memory":
+		regions := fake.NewRegions()
+		ps.NewClient = regions.Client
+		ps.NewKMSClient = regions.KMSClient
+		ps.NewSTSClient = regions.STSClient
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown backend %s, expected aws or memory\n", *backend)
+		os.Exit(commands.ExitUsage)
+	}
+	if *endpoint != "" {
+		ps.Session.Endpoint = *endpoint
+	}
+	if *kmsEndpoint != "" {
+		ps.Session.KMSEndpoint = *kmsEndpoint
+	}
+	if *stsEndpoint != "" {
+		ps.Session.STSEndpoint = *stsEndpoint
+	}
+	if *fips {
+		ps.Session.FIPS = true
+	}
+	if *dualStack {
+		ps.Session.DualStack = true
+	}
+	if *caBundle != "" {
+		ps.Session.CABundle = *caBundle
+	}
+	if *maxRetries >= 0 {
+		ps.Session.MaxRetries = maxRetries
+	}
+	err = ps.Session.Validate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error configuring AWS sessions:", err)
+		os.Exit(commands.ExitUsage)
+	}
+	ps.DryRun = *dryRun
+	if *readOnly {
+		ps.ReadOnly = true
+	}
+	if cfg.Default.AuditLog != "" {
+		err = ps.OpenAuditLog(cfg.Default.AuditLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening audit log %s: %s\n", cfg.Default.AuditLog, err)
+			os.Exit(commands.ExitError)
+		}
+	}
 	err = ps.NewParameterStore(true)
 	if err != nil {
-		shell.Println("Error initializing session. Is your authentication correct?", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error initializing session. Is your authentication correct?", err)
+		os.Exit(commands.ExitCode(err))
 	}
 	commands.Init(shell, &ps, &cfg)
 
@@ -47,12 +114,11 @@ func main() {
 		processStdin(shell)
 	} else if *file != "" {
 		processFile(shell, *file)
-	} else if len(flag.Args()) > 1 {
+	} else if len(flag.Args()) > 0 {
 		err := shell.Process(flag.Args()...)
 		if err != nil {
-			shell.Println("Error executing shell process:", err)
-			shell.Println("This might be a bug. Please open an issue at github.com/bwhaley/ssmsh.\n")
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(commands.ExitCode(err))
 		}
 	} else {
 		shell.Run()
@@ -61,23 +127,33 @@ func main() {
 }
 
 func processStdin(shell *ishell.Shell) {
-	data, err := ioutil.ReadAll(os.Stdin)
+	data, err := io.ReadAll(os.Stdin)
 	if err != nil {
-		shell.Println("Error reading from stdin:", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error reading from stdin:", err)
+		os.Exit(commands.ExitError)
 	}
-	processData(shell, string(data))
+	exitOnError(processData(shell, string(data)))
 }
 
 func processFile(shell *ishell.Shell, fn string) {
-	data, err := ioutil.ReadFile(fn)
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading from file:", err)
+		os.Exit(commands.ExitError)
+	}
+	exitOnError(processData(shell, string(data)))
+}
+
+// exitOnError prints an error from a script to stderr and exits with its exit code
+func exitOnError(err error) {
 	if err != nil {
-		shell.Println("Error reading from file:", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(commands.ExitCode(err))
 	}
-	processData(shell, string(data))
 }
 
-func processData(shell *ishell.Shell, data string) {
+// processData runs each line of a script, stopping at the first command that fails
+func processData(shell *ishell.Shell, data string) error {
 	lines := strings.Split(data, "\n")
 	for _, line := range lines {
 		if line == "" || string(line[0]) == "#" {
@@ -85,15 +161,12 @@ func processData(shell *ishell.Shell, data string) {
 		}
 		args, err := shellwords.Parse(line)
 		if err != nil {
-			msg := fmt.Errorf("Error parsing %s: %v", line, err)
-			shell.Println(msg)
-			os.Exit(1)
+			return fmt.Errorf("Error parsing %s: %v", line, err)
 		}
 		err = shell.Process(args...)
 		if err != nil {
-			msg := fmt.Errorf("Error executing %s: %v", line, err)
-			shell.Println(msg)
-			os.Exit(1)
+			return fmt.Errorf("Error executing %s: %w", line, err)
 		}
 	}
+	return nil
 }